@@ -17,6 +17,7 @@ import (
 	"github.com/TilmanGriesel/AlpineZen/pkg/logging"
 	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess/render"
 	"github.com/TilmanGriesel/AlpineZen/pkg/repository"
+	"github.com/TilmanGriesel/AlpineZen/pkg/timelapse"
 	"github.com/TilmanGriesel/AlpineZen/pkg/updater"
 	"github.com/TilmanGriesel/AlpineZen/pkg/util"
 	"github.com/TilmanGriesel/AlpineZen/pkg/wallpaper"
@@ -24,6 +25,14 @@ import (
 	"github.com/spf13/cobra"
 )
 
+const (
+	shutdownTimeout = 30 * time.Second
+)
+
+var (
+	timelapseTimeLayouts = []string{"2006-01-02T15:04", "2006-01-02"}
+)
+
 var (
 	version     = "[dev]"
 	buildNumber = "[0]"
@@ -52,6 +61,19 @@ type Config struct {
 	FontColorHex       string
 	DisableOSWallpaper bool
 
+	// Output Configuration
+	ExportPath string
+
+	// Archive Retention
+	ArchiveMaxAgeDays      int
+	ArchiveMaxSizeMB       int64
+	ArchiveMaxFiles        int
+	ArchiveHourlyAfterDays int
+
+	// Cache Quota
+	CacheMaxSizeMB        int64
+	CacheOrphanMaxAgeDays int
+
 	// Clock Position Offsets
 	ClockPositionConfig render.FontPositionConfig
 
@@ -175,6 +197,17 @@ func (app *Application) initialize(cmd *cobra.Command, args []string) error {
 	// Setup wallpaper configuration
 	app.WallpaperManager.WallpaperConfig.DisableClock = app.Config.DisableClock
 	app.WallpaperManager.WallpaperConfig.DisableOSWallpaperUpdate = app.Config.Headless
+	app.WallpaperManager.WallpaperConfig.ExportPath = app.Config.ExportPath
+	app.WallpaperManager.WallpaperConfig.ArchiveRetention = repository.RetentionPolicy{
+		MaxAge:      time.Duration(app.Config.ArchiveMaxAgeDays) * 24 * time.Hour,
+		MaxBytes:    app.Config.ArchiveMaxSizeMB * 1024 * 1024,
+		MaxFiles:    app.Config.ArchiveMaxFiles,
+		HourlyAfter: time.Duration(app.Config.ArchiveHourlyAfterDays) * 24 * time.Hour,
+	}
+	app.WallpaperManager.WallpaperConfig.CacheQuota = repository.QuotaPolicy{
+		MaxBytes:     app.Config.CacheMaxSizeMB * 1024 * 1024,
+		OrphanMaxAge: time.Duration(app.Config.CacheOrphanMaxAgeDays) * 24 * time.Hour,
+	}
 	app.WallpaperManager.WallpaperConfig.TargetDimensions = wallpaper.Dimensions{
 		Width:  app.Config.Width,
 		Height: app.Config.Height,
@@ -207,6 +240,142 @@ func (app *Application) initialize(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// waitForUpdate blocks until an in-flight wallpaper update has finished writing its outputs
+func (app *Application) waitForUpdate() {
+	if app.WallpaperManager == nil {
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		app.WallpaperManager.Shutdown()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(shutdownTimeout):
+		logger.WithField("timeout", shutdownTimeout).Warn("Timed out waiting for wallpaper update to finish")
+	}
+}
+
+type TimelapseFlags struct {
+	From        string
+	To          string
+	Output      string
+	Format      string
+	ArchiveDir  string
+	FPS         int
+	Width       int
+	Quality     int
+	MinInterval time.Duration
+	Dither      bool
+	Dedupe      bool
+	Threshold   int
+}
+
+// parseTimelapseTime parses a date or date-time flag in local time
+func parseTimelapseTime(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	for _, layout := range timelapseTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected YYYY-MM-DD or YYYY-MM-DDTHH:MM", value)
+}
+
+func (app *Application) exportTimelapse(flags *TimelapseFlags) error {
+	now := time.Now()
+	from, err := parseTimelapseTime(flags.From, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local))
+	if err != nil {
+		return err
+	}
+	to, err := parseTimelapseTime(flags.To, now)
+	if err != nil {
+		return err
+	}
+	// A plain date as upper bound includes the whole day
+	if len(flags.To) == len("2006-01-02") {
+		to = to.AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	archiveDir := flags.ArchiveDir
+	if archiveDir == "" {
+		appDirPath, err := util.GetAppDirPath()
+		if err != nil {
+			return fmt.Errorf("failed to get application directory path: %v", err)
+		}
+		archiveDir = filepath.Join(appDirPath, "archive")
+	}
+
+	count, err := timelapse.Export(timelapse.Config{
+		ArchiveDir:      archiveDir,
+		OutputPath:      flags.Output,
+		Format:          flags.Format,
+		From:            from,
+		To:              to,
+		MinInterval:     flags.MinInterval,
+		Width:           flags.Width,
+		FPS:             flags.FPS,
+		Quality:         flags.Quality,
+		Dither:          flags.Dither,
+		Dedupe:          flags.Dedupe,
+		DedupeThreshold: flags.Threshold,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export time-lapse: %v", err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"frames": count,
+		"output": flags.Output,
+	}).Info("Time-lapse exported. Exiting.")
+	os.Exit(0)
+	return nil
+}
+
+func (app *Application) timelapseCommand() *cobra.Command {
+	flags := &TimelapseFlags{}
+	cmd := &cobra.Command{
+		Use:   "timelapse",
+		Short: "Export archived wallpapers as a time-lapse",
+		Long:  `Export wallpapers archived in headless mode as an animated GIF or an MJPEG AVI video.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return app.exportTimelapse(flags)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.From, "from", "",
+		"Start of time range as YYYY-MM-DD or YYYY-MM-DDTHH:MM. Defaults to start of today.")
+	cmd.Flags().StringVar(&flags.To, "to", "",
+		"End of time range as YYYY-MM-DD or YYYY-MM-DDTHH:MM. Defaults to now.")
+	cmd.Flags().StringVarP(&flags.Output, "output", "o", "timelapse.gif",
+		"Output file path.")
+	cmd.Flags().StringVar(&flags.Format, "format", "",
+		"Output format: gif or avi. Defaults to output file extension.")
+	cmd.Flags().StringVar(&flags.ArchiveDir, "archive-dir", "",
+		"Archive directory to read frames from. Defaults to archive in application directory.")
+	cmd.Flags().IntVar(&flags.FPS, "fps", 12,
+		"Frames per second of exported time-lapse.")
+	cmd.Flags().IntVar(&flags.Width, "width", 960,
+		"Maximum frame width in pixels. Set to 0 to keep original size.")
+	cmd.Flags().IntVar(&flags.Quality, "quality", 85,
+		"JPEG quality of AVI frames, between 1 and 100.")
+	cmd.Flags().DurationVar(&flags.MinInterval, "min-interval", 0,
+		"Minimum time between selected frames, e.g. 30m.")
+	cmd.Flags().BoolVar(&flags.Dither, "dither", true,
+		"Apply Floyd-Steinberg dithering to GIF frames.")
+	cmd.Flags().BoolVar(&flags.Dedupe, "dedupe", true,
+		"Drop frames that are perceptually identical to previous frame.")
+	cmd.Flags().IntVar(&flags.Threshold, "dedupe-threshold", 2,
+		"Maximum hash distance for frames to count as identical.")
+
+	return cmd
+}
+
 func (app *Application) processFlags() {
 	// Usage
 	var rootCmd = &cobra.Command{
@@ -233,6 +402,26 @@ func (app *Application) processFlags() {
 	rootCmd.Flags().IntVar(&app.Config.Height, "wallpaper-height", 2160,
 		"Height of wallpaper in pixels.")
 
+	// Output flags
+	rootCmd.Flags().StringVar(&app.Config.ExportPath, "output-export-path", "",
+		"Path template for an additional copy of rendered wallpapers (e.g., 'renders/{{.Profile}}_{{.Date}}.jpg'). Overrides profile export_path.")
+
+	// Archive flags
+	rootCmd.Flags().IntVar(&app.Config.ArchiveMaxAgeDays, "archive-max-age-days", 0,
+		"Delete archived wallpapers older than this many days. 0 disables the limit.")
+	rootCmd.Flags().Int64Var(&app.Config.ArchiveMaxSizeMB, "archive-max-size-mb", 0,
+		"Maximum total size of archived wallpapers in megabytes. 0 disables the limit.")
+	rootCmd.Flags().IntVar(&app.Config.ArchiveMaxFiles, "archive-max-files", 0,
+		"Maximum number of archived wallpapers. 0 disables the limit.")
+	rootCmd.Flags().IntVar(&app.Config.ArchiveHourlyAfterDays, "archive-hourly-after-days", 0,
+		"Keep only one archived wallpaper per hour once older than this many days. 0 disables thinning.")
+
+	// Cache flags
+	rootCmd.Flags().Int64Var(&app.Config.CacheMaxSizeMB, "cache-max-size-mb", 0,
+		"Maximum total size of cached source images in megabytes. Least recently used profiles are evicted first. 0 disables the limit.")
+	rootCmd.Flags().IntVar(&app.Config.CacheOrphanMaxAgeDays, "cache-orphan-max-age-days", 14,
+		"Delete cached source images of profiles not used for this many days. 0 disables cleanup.")
+
 	// Clock flags
 	rootCmd.Flags().BoolVar(&app.Config.DisableClock, "clock-disable", false,
 		"Disable clock overlay on wallpaper.")
@@ -267,6 +456,8 @@ func (app *Application) processFlags() {
 	rootCmd.Flags().IntVar(&app.Config.LogLevel, "loglevel", 1,
 		"Logging verbosity level: 0 = Warn, 1 = Info, 2 = Debug, 3 = Trace.")
 
+	rootCmd.AddCommand(app.timelapseCommand())
+
 	if err := rootCmd.Execute(); err != nil {
 		logger.Fatalf("Application error: %v", err)
 	}
@@ -294,6 +485,7 @@ func main() {
 					logger.WithField("signal", sig).Info("Received termination signal. Initiating shutdown")
 					cancel()
 					app.UpdaterManager.StopUpdater()
+					app.waitForUpdate()
 					logger.Info("Graceful shutdown complete")
 					os.Exit(0)
 				}
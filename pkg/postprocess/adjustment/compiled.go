@@ -0,0 +1,271 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"image"
+	"math"
+	"runtime"
+	"sync"
+)
+
+const (
+	// thresholdRange bounds inputs covered by output level thresholds. Hue
+	// rotation can push values above 1, larger values are evaluated directly.
+	thresholdRange = 2.0
+
+	// levelGridScale is the number of level grid cells per unit of input
+	levelGridScale = 4096
+
+	// maxCompiledEntries bounds the compiled table cache. Composite profiles
+	// enhance each tile with its own settings.
+	maxCompiledEntries = 16
+)
+
+// compileKey holds every setting compiled tables depend on
+type compileKey struct {
+	gains                  WhiteBalanceGains
+	blackPoint, whitePoint float64
+	contrast, saturation   float64
+	brightness, hue        float64
+	gamma, shadowStrength  float64
+	curves                 [3][256]uint8
+}
+
+// compiledCache keeps compiled tables between calls, so unchanged settings
+// are not compiled again on every frame
+var compiledCache = struct {
+	sync.Mutex
+	entries map[compileKey]*compiledEnhancer
+}{entries: make(map[compileKey]*compiledEnhancer)}
+
+// compiledEnhancer holds the per-pixel stages of an ImageEnhancer reduced to
+// lookup tables. Channel-independent stages become 256-entry tables; only
+// saturation and hue rotation mix channels and run as matrices.
+type compiledEnhancer struct {
+	// pre maps input bytes through white balance gains, black point, white
+	// point and contrast
+	pre [3][256]float64
+
+	// direct maps input bytes to output bytes when no stage mixes channels
+	direct *[3][256]uint8
+
+	saturation float64
+	brightness float64
+	hue        *[3][3]float64
+
+	// thresholds holds the smallest input reaching each output level after
+	// gamma and shadow strength. grid holds the level at the start of each
+	// grid cell, so lookups only step over the few thresholds inside a cell.
+	thresholds *[256]float64
+	grid       []uint8
+
+	// curves holds tone curves on output bytes
+	curves [3][256]uint8
+}
+
+// compile returns lookup tables for the current settings, reusing tables
+// compiled earlier for the same settings
+func (e *ImageEnhancer) compile(gains WhiteBalanceGains, blackPoint, whitePoint float64) *compiledEnhancer {
+	key := compileKey{
+		gains: gains, blackPoint: blackPoint, whitePoint: whitePoint,
+		contrast: e.Contrast, saturation: e.Saturation,
+		brightness: e.Brightness, hue: e.Hue,
+		gamma: e.Gamma, shadowStrength: e.ShadowStrength,
+		curves: e.curveTables(),
+	}
+
+	compiledCache.Lock()
+	defer compiledCache.Unlock()
+
+	if c, ok := compiledCache.entries[key]; ok {
+		return c
+	}
+	if len(compiledCache.entries) >= maxCompiledEntries {
+		clear(compiledCache.entries)
+	}
+
+	c := e.build(key)
+	compiledCache.entries[key] = c
+	return c
+}
+
+// curveTables combines master and channel tone curves into one table per channel
+func (e *ImageEnhancer) curveTables() [3][256]uint8 {
+	curves := [3][256]uint8{CurveTable(nil), CurveTable(nil), CurveTable(nil)}
+	if e.ToneCurve.Empty() {
+		return curves
+	}
+
+	master := CurveTable(e.ToneCurve.Master)
+	for ch, points := range [][]CurvePoint{e.ToneCurve.Red, e.ToneCurve.Green, e.ToneCurve.Blue} {
+		channel := CurveTable(points)
+		for v := range channel {
+			curves[ch][v] = master[channel[v]]
+		}
+	}
+	return curves
+}
+
+// build compiles lookup tables for key
+func (e *ImageEnhancer) build(key compileKey) *compiledEnhancer {
+	gains, blackPoint, whitePoint := key.gains, key.blackPoint, key.whitePoint
+	c := &compiledEnhancer{saturation: e.Saturation, brightness: e.Brightness, curves: key.curves}
+
+	for ch := 0; ch < 3; ch++ {
+		for v := 0; v < 256; v++ {
+			x := float64(v)
+			if gains[ch] != 1 {
+				x = math.Floor(Clamp(x*gains[ch], 0, 255) + 0.5)
+			}
+			x = AdjustWhitePoint(AdjustBlackPoint(x/255.0, blackPoint), whitePoint)
+			c.pre[ch][v] = (x-0.5)*e.Contrast + 0.5
+		}
+	}
+
+	if e.Saturation == 1 && e.Hue == 0 {
+		c.direct = new([3][256]uint8)
+		for ch := 0; ch < 3; ch++ {
+			for v := 0; v < 256; v++ {
+				c.direct[ch][v] = c.curves[ch][uint8(e.finish(AdjustBrightness(c.pre[ch][v], e.Brightness))*255)]
+			}
+		}
+		return c
+	}
+
+	if e.Hue != 0 {
+		u := math.Cos(e.Hue * math.Pi / 180.0)
+		w := math.Sin(e.Hue * math.Pi / 180.0)
+		c.hue = &[3][3]float64{
+			{.299 + .701*u + .168*w, .587 - .587*u + .330*w, .114 - .114*u - .497*w},
+			{.299 - .299*u - .328*w, .587 + .413*u + .035*w, .114 - .114*u + .292*w},
+			{.299 - .3*u + 1.25*w, .587 - .588*u - 1.05*w, .114 + .886*u - .203*w},
+		}
+	}
+
+	// Thresholds need a monotone response, other settings are evaluated per pixel
+	if e.Gamma > 0 && e.ShadowStrength >= 0 {
+		c.thresholds = new([256]float64)
+		for level := range c.thresholds {
+			c.thresholds[level] = e.levelThreshold(level)
+		}
+
+		c.grid = make([]uint8, int(thresholdRange*levelGridScale)+1)
+		level := 0
+		for i := range c.grid {
+			x := float64(i) / levelGridScale
+			for level < 255 && c.thresholds[level+1] <= x {
+				level++
+			}
+			c.grid[i] = uint8(level)
+		}
+	}
+
+	return c
+}
+
+// levelThreshold finds the smallest input whose finished value quantizes to
+// at least level, by bisection
+func (e *ImageEnhancer) levelThreshold(level int) float64 {
+	reaches := func(x float64) bool { return int(uint8(e.finish(x)*255)) >= level }
+
+	low, high := 0.0, thresholdRange
+	if reaches(low) {
+		return math.Inf(-1)
+	}
+	if !reaches(high) {
+		return math.Inf(1)
+	}
+	for {
+		mid := (low + high) / 2
+		if mid == low || mid == high {
+			return high
+		}
+		if reaches(mid) {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+}
+
+// finish applies gamma and shadow strength to a single channel value
+func (e *ImageEnhancer) finish(x float64) float64 {
+	return Clamp(AdjustShadowStrength(ApplyGamma(max(x, 0), e.Gamma), e.ShadowStrength), 0, 1)
+}
+
+// level quantizes a value after gamma and shadow strength to an output byte
+func (c *compiledEnhancer) level(e *ImageEnhancer, x float64) uint8 {
+	if c.thresholds == nil || x > thresholdRange {
+		return uint8(e.finish(x) * 255)
+	}
+	if x <= 0 {
+		return c.grid[0]
+	}
+
+	level := c.grid[int(x*levelGridScale)]
+	for level < 255 && c.thresholds[level+1] <= x {
+		level++
+	}
+	return level
+}
+
+// apply runs compiled stages on img in place, in parallel row bands
+func (c *compiledEnhancer) apply(e *ImageEnhancer, img *image.NRGBA) {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+
+	parallelRows(height, func(start, end int) {
+		for y := start; y < end; y++ {
+			row := img.Pix[y*img.Stride : y*img.Stride+width*4]
+
+			if c.direct != nil {
+				for i := 0; i < len(row); i += 4 {
+					row[i] = c.direct[0][row[i]]
+					row[i+1] = c.direct[1][row[i+1]]
+					row[i+2] = c.direct[2][row[i+2]]
+				}
+				continue
+			}
+
+			for i := 0; i < len(row); i += 4 {
+				r, g, b := c.pre[0][row[i]], c.pre[1][row[i+1]], c.pre[2][row[i+2]]
+
+				avg := (r + g + b) / 3.0
+				r = Clamp(avg+(r-avg)*c.saturation+c.brightness, 0, 1)
+				g = Clamp(avg+(g-avg)*c.saturation+c.brightness, 0, 1)
+				b = Clamp(avg+(b-avg)*c.saturation+c.brightness, 0, 1)
+
+				if c.hue != nil {
+					m := c.hue
+					r, g, b = m[0][0]*r+m[0][1]*g+m[0][2]*b, m[1][0]*r+m[1][1]*g+m[1][2]*b, m[2][0]*r+m[2][1]*g+m[2][2]*b
+				}
+
+				row[i] = c.curves[0][c.level(e, r)]
+				row[i+1] = c.curves[1][c.level(e, g)]
+				row[i+2] = c.curves[2][c.level(e, b)]
+			}
+		}
+	})
+}
+
+// parallelRows splits height rows into bands and runs fn on them concurrently
+func parallelRows(height int, fn func(start, end int)) {
+	workers := min(runtime.GOMAXPROCS(0), height)
+	if workers <= 1 {
+		fn(0, height)
+		return
+	}
+
+	band := (height + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < height; start += band {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, min(start+band, height))
+	}
+	wg.Wait()
+}
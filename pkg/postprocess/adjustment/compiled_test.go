@@ -0,0 +1,183 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+// referenceEnhancements evaluates every stage per pixel, as ApplyEnhancements
+// did before compilation
+func referenceEnhancements(e *ImageEnhancer, img image.Image) image.Image {
+	img = applyGains(img, e.whiteBalanceGains(img))
+	blackPoint, whitePoint := e.levels(img)
+
+	var result image.Image = imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		r, g, b := e.adjustPixel(float64(c.R)/255.0, float64(c.G)/255.0, float64(c.B)/255.0, blackPoint, whitePoint)
+		return color.NRGBA{R: uint8(r * 255), G: uint8(g * 255), B: uint8(b * 255), A: c.A}
+	})
+	if !e.ToneCurve.Empty() {
+		result = ApplyToneCurve(result, e.ToneCurve)
+	}
+	return result
+}
+
+func randomImage(width, height int, seed int64) *image.NRGBA {
+	random := rand.New(rand.NewSource(seed))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	random.Read(img.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+// benchmarkImage returns a smooth 4K frame, closer to a sky photo than noise
+func benchmarkImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 3840, 2160))
+	for y := 0; y < 2160; y++ {
+		for x := 0; x < 3840; x++ {
+			i := img.PixOffset(x, y)
+			img.Pix[i] = uint8(x * 255 / 3839)
+			img.Pix[i+1] = uint8(y * 255 / 2159)
+			img.Pix[i+2] = uint8((x + y) * 255 / 5998)
+			img.Pix[i+3] = 255
+		}
+	}
+	return img
+}
+
+func benchmarkEnhancer() *ImageEnhancer {
+	enhancer := NewImageEnhancer()
+	enhancer.Contrast = 1.1
+	enhancer.Saturation = 1.2
+	enhancer.Brightness = 0.02
+	enhancer.Hue = 4
+	enhancer.Gamma = 1.1
+	enhancer.BlackPoint = 0.02
+	enhancer.WhitePoint = 0.98
+	enhancer.ShadowStrength = 1.05
+	return enhancer
+}
+
+func TestCompiledEnhancementsMatchReference(t *testing.T) {
+	img := randomImage(64, 64, 1)
+
+	direct := NewImageEnhancer()
+	direct.Contrast = 1.3
+	direct.Gamma = 0.8
+	direct.BlackPoint = 0.05
+	direct.Temperature = 0.3
+	direct.ToneCurve = ToneCurve{Master: []CurvePoint{{0, 0}, {0.5, 0.6}, {1, 1}}}
+
+	mixing := benchmarkEnhancer()
+	mixing.Gamma = 2.2
+
+	saturationOnly := NewImageEnhancer()
+	saturationOnly.Saturation = 0.4
+	saturationOnly.Brightness = -0.1
+
+	for name, enhancer := range map[string]*ImageEnhancer{"direct": direct, "mixing": mixing, "saturation": saturationOnly} {
+		expected := imaging.Clone(referenceEnhancements(enhancer, img))
+		actual := imaging.Clone(enhancer.ApplyEnhancements(img))
+
+		assert.Equal(t, expected.Pix, actual.Pix, "%s: compiled tables should match per-pixel evaluation", name)
+	}
+}
+
+func TestCompileSelectsTables(t *testing.T) {
+	enhancer := NewImageEnhancer()
+	enhancer.Contrast = 1.2
+	assert.NotNil(t, enhancer.compile(WhiteBalanceGains{1, 1, 1}, 0, 1).direct, "Settings without saturation or hue should compile to direct tables")
+
+	enhancer.Saturation = 1.3
+	compiled := enhancer.compile(WhiteBalanceGains{1, 1, 1}, 0, 1)
+	assert.Nil(t, compiled.direct, "Saturation mixes channels and needs the matrix path")
+	assert.NotNil(t, compiled.thresholds, "Monotone gamma and shadow strength should compile to thresholds")
+
+	for _, x := range []float64{-0.2, 0, 0.001, 0.25, 0.5, 0.999, 1, 1.4} {
+		assert.Equal(t, uint8(enhancer.finish(x)*255), compiled.level(enhancer, x), "Threshold lookup should match direct evaluation at %v", x)
+	}
+}
+
+func TestCompileReusesTables(t *testing.T) {
+	gains := WhiteBalanceGains{1, 1, 1}
+	enhancer := benchmarkEnhancer()
+	compiled := enhancer.compile(gains, 0.02, 0.98)
+
+	assert.Same(t, compiled, benchmarkEnhancer().compile(gains, 0.02, 0.98), "Same settings should reuse compiled tables")
+	assert.NotSame(t, compiled, enhancer.compile(WhiteBalanceGains{1.1, 1, 0.9}, 0.02, 0.98), "Different gains should compile new tables")
+	assert.NotSame(t, compiled, enhancer.compile(gains, 0.05, 0.98), "Different levels should compile new tables")
+
+	enhancer.Saturation = 0.8
+	assert.NotSame(t, compiled, enhancer.compile(gains, 0.02, 0.98), "Changed settings should compile new tables")
+
+	enhancer = benchmarkEnhancer()
+	enhancer.ToneCurve = ToneCurve{Master: []CurvePoint{{0, 0}, {0.5, 0.6}, {1, 1}}}
+	assert.NotSame(t, compiled, enhancer.compile(gains, 0.02, 0.98), "Changed tone curve should compile new tables")
+
+	for i := 0; i < maxCompiledEntries; i++ {
+		enhancer.compile(gains, float64(i)/100, 1)
+	}
+	assert.LessOrEqual(t, len(compiledCache.entries), maxCompiledEntries, "Cache should stay bounded")
+}
+
+func TestParallelRows(t *testing.T) {
+	for _, height := range []int{0, 1, 7, 100} {
+		seen := make([]int, height)
+		counts := make(chan [2]int, height+1)
+		parallelRows(height, func(start, end int) { counts <- [2]int{start, end} })
+		close(counts)
+		for band := range counts {
+			for y := band[0]; y < band[1]; y++ {
+				seen[y]++
+			}
+		}
+		for y, count := range seen {
+			assert.Equal(t, 1, count, "Row %d of %d should be processed exactly once", y, height)
+		}
+	}
+}
+
+func BenchmarkApplyEnhancements(b *testing.B) {
+	img := benchmarkImage()
+	enhancer := benchmarkEnhancer()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		enhancer.ApplyEnhancements(img)
+	}
+}
+
+func BenchmarkApplyEnhancementsDirect(b *testing.B) {
+	img := benchmarkImage()
+	enhancer := benchmarkEnhancer()
+	enhancer.Saturation = 1
+	enhancer.Hue = 0
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		enhancer.ApplyEnhancements(img)
+	}
+}
+
+func BenchmarkApplyEnhancementsReference(b *testing.B) {
+	img := benchmarkImage()
+	enhancer := benchmarkEnhancer()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		referenceEnhancements(enhancer, img)
+	}
+}
@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+// CurvePoint is a control point of a tone curve, both coordinates in [0, 1]
+type CurvePoint [2]float64
+
+// ToneCurve holds per channel curves that run before the master curve. Empty
+// curves are the identity.
+type ToneCurve struct {
+	Master []CurvePoint `yaml:"master"`
+	Red    []CurvePoint `yaml:"red"`
+	Green  []CurvePoint `yaml:"green"`
+	Blue   []CurvePoint `yaml:"blue"`
+}
+
+// Empty reports whether no curve has control points
+func (t ToneCurve) Empty() bool {
+	return len(t.Master) == 0 && len(t.Red) == 0 && len(t.Green) == 0 && len(t.Blue) == 0
+}
+
+// Validate checks control points are in range and have distinct inputs
+func (t ToneCurve) Validate() error {
+	for name, points := range map[string][]CurvePoint{"master": t.Master, "red": t.Red, "green": t.Green, "blue": t.Blue} {
+		if len(points) == 1 {
+			return fmt.Errorf("%s curve needs at least 2 points", name)
+		}
+
+		seen := make(map[float64]bool)
+		for _, point := range points {
+			if point[0] < 0 || point[0] > 1 || point[1] < 0 || point[1] > 1 {
+				return fmt.Errorf("%s curve point %v out of range [0, 1]", name, point)
+			}
+			if seen[point[0]] {
+				return fmt.Errorf("%s curve has duplicate input %v", name, point[0])
+			}
+			seen[point[0]] = true
+		}
+	}
+	return nil
+}
+
+// CurveTable evaluates a monotone cubic spline through points for all 8-bit
+// values. Monotone interpolation avoids the overshoot natural splines show
+// between steep control points. Inputs outside the first and last point are
+// held flat.
+func CurveTable(points []CurvePoint) [256]uint8 {
+	var table [256]uint8
+	for i, value := range curveSamples(points) {
+		table[i] = uint8(Clamp(value, 0, 1)*255 + 0.5)
+	}
+	return table
+}
+
+// curveSamples evaluates the curve at 256 evenly spaced inputs
+func curveSamples(points []CurvePoint) [256]float64 {
+	var table [256]float64
+	if len(points) < 2 {
+		for i := range table {
+			table[i] = float64(i) / 255.0
+		}
+		return table
+	}
+
+	sorted := append([]CurvePoint(nil), points...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i][0] < sorted[j][0] })
+
+	n := len(sorted)
+	slopes := make([]float64, n-1)
+	for i := 0; i < n-1; i++ {
+		slopes[i] = (sorted[i+1][1] - sorted[i][1]) / (sorted[i+1][0] - sorted[i][0])
+	}
+
+	// Fritsch-Carlson tangents
+	tangents := make([]float64, n)
+	tangents[0] = slopes[0]
+	tangents[n-1] = slopes[n-2]
+	for i := 1; i < n-1; i++ {
+		if slopes[i-1]*slopes[i] <= 0 {
+			tangents[i] = 0
+		} else {
+			tangents[i] = (slopes[i-1] + slopes[i]) / 2
+		}
+	}
+	for i := 0; i < n-1; i++ {
+		if slopes[i] == 0 {
+			tangents[i], tangents[i+1] = 0, 0
+			continue
+		}
+		a := tangents[i] / slopes[i]
+		b := tangents[i+1] / slopes[i]
+		if sum := a*a + b*b; sum > 9 {
+			tau := 3 / math.Sqrt(sum)
+			tangents[i] = tau * a * slopes[i]
+			tangents[i+1] = tau * b * slopes[i]
+		}
+	}
+
+	segment := 0
+	for i := range table {
+		x := float64(i) / 255.0
+
+		var y float64
+		switch {
+		case x <= sorted[0][0]:
+			y = sorted[0][1]
+		case x >= sorted[n-1][0]:
+			y = sorted[n-1][1]
+		default:
+			for segment < n-2 && x > sorted[segment+1][0] {
+				segment++
+			}
+			x0, x1 := sorted[segment][0], sorted[segment+1][0]
+			y0, y1 := sorted[segment][1], sorted[segment+1][1]
+			h := x1 - x0
+			t := (x - x0) / h
+			t2, t3 := t*t, t*t*t
+			y = (2*t3-3*t2+1)*y0 + (t3-2*t2+t)*h*tangents[segment] + (-2*t3+3*t2)*y1 + (t3-t2)*h*tangents[segment+1]
+		}
+
+		table[i] = y
+	}
+
+	return table
+}
+
+// toneCurveSamples holds sampled curves for high precision evaluation
+type toneCurveSamples struct {
+	master, red, green, blue [256]float64
+}
+
+func (t ToneCurve) samples() *toneCurveSamples {
+	return &toneCurveSamples{
+		master: curveSamples(t.Master),
+		red:    curveSamples(t.Red),
+		green:  curveSamples(t.Green),
+		blue:   curveSamples(t.Blue),
+	}
+}
+
+// sampleCurve interpolates linearly between curve samples
+func sampleCurve(samples *[256]float64, value float64) float64 {
+	position := Clamp(value, 0, 1) * 255
+	low := int(position)
+	if low >= 255 {
+		return Clamp(samples[255], 0, 1)
+	}
+	fraction := position - float64(low)
+	return Clamp(samples[low]*(1-fraction)+samples[low+1]*fraction, 0, 1)
+}
+
+func (s *toneCurveSamples) apply(r, g, b float64) (float64, float64, float64) {
+	return sampleCurve(&s.master, sampleCurve(&s.red, r)),
+		sampleCurve(&s.master, sampleCurve(&s.green, g)),
+		sampleCurve(&s.master, sampleCurve(&s.blue, b))
+}
+
+// ApplyToneCurve maps every pixel through channel curves followed by the master curve
+func ApplyToneCurve(img image.Image, curve ToneCurve) *image.NRGBA {
+	dst := imaging.Clone(img)
+	if curve.Empty() {
+		return dst
+	}
+
+	master := CurveTable(curve.Master)
+	red := CurveTable(curve.Red)
+	green := CurveTable(curve.Green)
+	blue := CurveTable(curve.Blue)
+
+	for i := 0; i < len(dst.Pix); i += 4 {
+		dst.Pix[i] = master[red[dst.Pix[i]]]
+		dst.Pix[i+1] = master[green[dst.Pix[i+1]]]
+		dst.Pix[i+2] = master[blue[dst.Pix[i+2]]]
+	}
+
+	return dst
+}
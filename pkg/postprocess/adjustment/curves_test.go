@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurveTable(t *testing.T) {
+	identity := CurveTable(nil)
+	assert.Equal(t, uint8(128), identity[128], "Empty curve should be identity")
+
+	linear := CurveTable([]CurvePoint{{0, 0}, {1, 1}})
+	assert.Equal(t, uint8(64), linear[64], "Two point curve should be linear")
+
+	sCurve := CurveTable([]CurvePoint{{0, 0}, {0.25, 0.15}, {0.75, 0.85}, {1, 1}})
+	assert.Less(t, sCurve[64], uint8(64), "S-curve should darken shadows")
+	assert.Greater(t, sCurve[192], uint8(192), "S-curve should brighten highlights")
+	for i := 1; i < 256; i++ {
+		assert.GreaterOrEqual(t, sCurve[i], sCurve[i-1], "Monotone control points should give a monotone curve")
+	}
+
+	clipped := CurveTable([]CurvePoint{{0.2, 0}, {0.8, 1}})
+	assert.Equal(t, uint8(0), clipped[10], "Inputs below first point should hold first value")
+	assert.Equal(t, uint8(255), clipped[250], "Inputs above last point should hold last value")
+}
+
+func TestToneCurveValidate(t *testing.T) {
+	assert.NoError(t, ToneCurve{Master: []CurvePoint{{0, 0}, {1, 1}}}.Validate(), "Valid curve should pass")
+	assert.Error(t, ToneCurve{Red: []CurvePoint{{0.5, 0.5}}}.Validate(), "Single point curve should fail")
+	assert.Error(t, ToneCurve{Blue: []CurvePoint{{0, 0}, {1.5, 1}}}.Validate(), "Out of range point should fail")
+	assert.Error(t, ToneCurve{Green: []CurvePoint{{0.5, 0}, {0.5, 1}}}.Validate(), "Duplicate inputs should fail")
+}
+
+func TestApplyToneCurve(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{100, 100, 100, 255})
+
+	result := ApplyToneCurve(img, ToneCurve{Red: []CurvePoint{{0, 1}, {1, 0}}})
+	assert.Equal(t, color.NRGBA{155, 100, 100, 255}, result.NRGBAAt(0, 0), "Red curve should only affect red channel")
+}
+
+func TestRGBToHSLRoundTrip(t *testing.T) {
+	h, s, l := RGBToHSL(0.2, 0.4, 0.8)
+	assert.InDelta(t, 220, h, 0.01, "Hue should be computed in degrees")
+
+	r, g, b := HSLToRGB(h, s, l)
+	assert.InDelta(t, 0.2, r, 0.0001, "Red should survive round trip")
+	assert.InDelta(t, 0.4, g, 0.0001, "Green should survive round trip")
+	assert.InDelta(t, 0.8, b, 0.0001, "Blue should survive round trip")
+}
+
+func TestApplyHSL(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{60, 100, 200, 255})
+	img.SetNRGBA(1, 0, color.NRGBA{60, 180, 60, 255})
+	img.SetNRGBA(2, 0, color.NRGBA{128, 128, 128, 255})
+
+	result := ApplyHSL(img, map[string]HSLShift{"blues": {Saturation: 0.5}, "greens": {Saturation: -1}})
+
+	_, blueSaturation, _ := RGBToHSL(float64(result.Pix[0])/255, float64(result.Pix[1])/255, float64(result.Pix[2])/255)
+	_, originalSaturation, _ := RGBToHSL(60.0/255, 100.0/255, 200.0/255)
+	assert.Greater(t, blueSaturation, originalSaturation, "Blues should be more saturated")
+
+	green := result.NRGBAAt(1, 0)
+	assert.InDelta(t, int(green.R), int(green.G), 2, "Greens should be desaturated to gray")
+	assert.Equal(t, color.NRGBA{128, 128, 128, 255}, result.NRGBAAt(2, 0), "Grays should stay untouched")
+
+	assert.Error(t, ValidateHSL(map[string]HSLShift{"browns": {}}), "Unknown band should fail validation")
+	assert.Error(t, ValidateHSL(map[string]HSLShift{"blues": {Saturation: 2}}), "Out of range shift should fail validation")
+}
@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	// hslBandWidth is the hue distance in degrees at which a band fades out
+	hslBandWidth = 45.0
+)
+
+// HSLBands maps selectable hue ranges to their center hue in degrees
+var HSLBands = map[string]float64{
+	"reds":     0,
+	"oranges":  30,
+	"yellows":  60,
+	"greens":   120,
+	"aquas":    180,
+	"blues":    240,
+	"purples":  270,
+	"magentas": 300,
+}
+
+// HSLShift adjusts one hue band. Hue is a rotation in degrees, Saturation and
+// Lightness are relative changes in [-1, 1].
+type HSLShift struct {
+	Hue        float64 `yaml:"hue"`
+	Saturation float64 `yaml:"saturation"`
+	Lightness  float64 `yaml:"lightness"`
+}
+
+// ValidateHSL checks band names and shift ranges
+func ValidateHSL(adjustments map[string]HSLShift) error {
+	for band, shift := range adjustments {
+		if _, ok := HSLBands[band]; !ok {
+			return fmt.Errorf("unknown HSL band: %q", band)
+		}
+		if shift.Hue < -180 || shift.Hue > 180 {
+			return fmt.Errorf("%s hue shift must be between -180 and 180: %v", band, shift.Hue)
+		}
+		if shift.Saturation < -1 || shift.Saturation > 1 || shift.Lightness < -1 || shift.Lightness > 1 {
+			return fmt.Errorf("%s saturation and lightness shifts must be between -1 and 1", band)
+		}
+	}
+	return nil
+}
+
+// hueDistance returns the shortest angular distance between two hues
+func hueDistance(a, b float64) float64 {
+	d := math.Abs(math.Mod(a-b, 360))
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+// hslShiftTable precomputes weighted shifts for every whole degree of hue
+func hslShiftTable(adjustments map[string]HSLShift) [360]HSLShift {
+	var table [360]HSLShift
+	for hue := range table {
+		for band, shift := range adjustments {
+			d := hueDistance(float64(hue), HSLBands[band])
+			if d >= hslBandWidth {
+				continue
+			}
+			weight := 0.5 * (1 + math.Cos(math.Pi*d/hslBandWidth))
+			table[hue].Hue += shift.Hue * weight
+			table[hue].Saturation += shift.Saturation * weight
+			table[hue].Lightness += shift.Lightness * weight
+		}
+	}
+	return table
+}
+
+// ApplyHSL shifts hue, saturation and lightness of selected hue bands. Effects
+// scale with pixel saturation, so neutral grays stay untouched.
+func ApplyHSL(img image.Image, adjustments map[string]HSLShift) *image.NRGBA {
+	dst := imaging.Clone(img)
+	if len(adjustments) == 0 {
+		return dst
+	}
+
+	table := hslShiftTable(adjustments)
+	for i := 0; i < len(dst.Pix); i += 4 {
+		r, g, b := shiftHSL(float64(dst.Pix[i])/255.0, float64(dst.Pix[i+1])/255.0, float64(dst.Pix[i+2])/255.0, &table)
+		dst.Pix[i] = uint8(Clamp(r, 0, 1)*255 + 0.5)
+		dst.Pix[i+1] = uint8(Clamp(g, 0, 1)*255 + 0.5)
+		dst.Pix[i+2] = uint8(Clamp(b, 0, 1)*255 + 0.5)
+	}
+
+	return dst
+}
+
+// shiftHSL applies weighted band shifts to a color with channels in [0, 1]
+func shiftHSL(r, g, b float64, table *[360]HSLShift) (float64, float64, float64) {
+	h, s, l := RGBToHSL(r, g, b)
+	if s == 0 {
+		return r, g, b
+	}
+
+	shift := table[int(math.Round(h))%360]
+	h = math.Mod(h+shift.Hue*s+360, 360)
+
+	if shift.Lightness < 0 {
+		l *= 1 + shift.Lightness*s
+	} else {
+		l += (1 - l) * shift.Lightness * s
+	}
+	s = Clamp(s*(1+shift.Saturation), 0, 1)
+
+	return HSLToRGB(h, s, l)
+}
+
+// RGBToHSL converts RGB in [0, 1] to hue in degrees, saturation and lightness in [0, 1]
+func RGBToHSL(r, g, b float64) (float64, float64, float64) {
+	maxValue := math.Max(r, math.Max(g, b))
+	minValue := math.Min(r, math.Min(g, b))
+	l := (maxValue + minValue) / 2
+
+	delta := maxValue - minValue
+	if delta == 0 {
+		return 0, 0, l
+	}
+
+	s := delta / (1 - math.Abs(2*l-1))
+
+	var h float64
+	switch maxValue {
+	case r:
+		h = math.Mod((g-b)/delta, 6)
+	case g:
+		h = (b-r)/delta + 2
+	default:
+		h = (r-g)/delta + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+
+	return h, Clamp(s, 0, 1), l
+}
+
+// HSLToRGB converts hue in degrees, saturation and lightness in [0, 1] to RGB
+func HSLToRGB(h, s, l float64) (float64, float64, float64) {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return r + m, g + m, b + m
+}
@@ -7,7 +7,9 @@ package adjustment
 import (
 	"image"
 	"image/color"
+	"image/draw"
 
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
 	"github.com/disintegration/imaging"
 )
 
@@ -16,6 +18,14 @@ const (
 )
 
 type ImageEnhancer struct {
+	// White balance runs first. Temperature and Tint are relative in [-1, 1],
+	// TemperatureKelvin sets the scene's light temperature, AutoWhiteBalance
+	// estimates the cast from the image.
+	Temperature       float64
+	Tint              float64
+	TemperatureKelvin float64
+	AutoWhiteBalance  string
+
 	Contrast       float64
 	Saturation     float64
 	Brightness     float64
@@ -24,6 +34,23 @@ type ImageEnhancer struct {
 	BlackPoint     float64
 	WhitePoint     float64
 	ShadowStrength float64
+
+	// Local contrast, disabled when CLAHEClipLimit is zero
+	CLAHEClipLimit float64
+	CLAHETiles     int
+
+	// AutoLevels derives black and white points from the histogram, clipping
+	// AutoLevelsClip of pixels at either end
+	AutoLevels     bool
+	AutoLevelsClip float64
+
+	// Tone curves and selective hue band adjustments run after global adjustments
+	ToneCurve      ToneCurve
+	HSLAdjustments map[string]HSLShift
+
+	// LinearLight runs white balance and global adjustments on linear light
+	// values in ApplyEnhancements64. Tone curves and HSL stay on encoded values.
+	LinearLight bool
 }
 
 func NewImageEnhancer() *ImageEnhancer {
@@ -36,55 +63,197 @@ func NewImageEnhancer() *ImageEnhancer {
 		BlackPoint:     0.0,
 		WhitePoint:     1.0,
 		ShadowStrength: 1.0,
+		CLAHETiles:     DefaultCLAHETiles,
+		AutoLevelsClip: DefaultAutoLevelsClip,
 	}
 }
 
-func (e *ImageEnhancer) ApplyEnhancements(img image.Image) image.Image {
+// whiteBalanceGains combines automatic, Kelvin and relative white balance
+func (e *ImageEnhancer) whiteBalanceGains(img image.Image) WhiteBalanceGains {
+	gains := WhiteBalanceGains{1, 1, 1}
+	if e.AutoWhiteBalance != "" {
+		gains = gains.Multiply(AutoWhiteBalanceGains(img, e.AutoWhiteBalance))
+	}
+	if e.TemperatureKelvin > 0 {
+		gains = gains.Multiply(KelvinGains(e.TemperatureKelvin))
+	}
+	if e.Temperature != 0 || e.Tint != 0 {
+		gains = gains.Multiply(RelativeGains(e.Temperature, e.Tint))
+	}
+	if gains.Identity() {
+		return gains
+	}
+	return gains.Normalize()
+}
+
+// levels returns black and white point parameters, picked from the histogram in auto mode
+func (e *ImageEnhancer) levels(img image.Image) (float64, float64) {
+	if !e.AutoLevels {
+		return e.BlackPoint, e.WhitePoint
+	}
+
+	clip := e.AutoLevelsClip
+	if clip <= 0 {
+		clip = DefaultAutoLevelsClip
+	}
+	low, high := AutoLevels(img, clip)
+
+	// White point applies after black point, so express it on the shifted scale
+	return low, (high - low) / (1 - low)
+}
+
+// applyGains multiplies channels with white balance gains in 8-bit precision
+func applyGains(img image.Image, gains WhiteBalanceGains) image.Image {
+	if gains.Identity() {
+		return img
+	}
 	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
-		r := float64(c.R) / 255.0
-		g := float64(c.G) / 255.0
-		b := float64(c.B) / 255.0
+		return color.NRGBA{
+			R: uint8(Clamp(float64(c.R)*gains[0], 0, 255) + 0.5),
+			G: uint8(Clamp(float64(c.G)*gains[1], 0, 255) + 0.5),
+			B: uint8(Clamp(float64(c.B)*gains[2], 0, 255) + 0.5),
+			A: c.A,
+		}
+	})
+}
 
-		r = AdjustBlackPoint(r, e.BlackPoint)
-		g = AdjustBlackPoint(g, e.BlackPoint)
-		b = AdjustBlackPoint(b, e.BlackPoint)
+// adjustPixel runs global adjustments on a color with channels in [0, 1]
+func (e *ImageEnhancer) adjustPixel(r, g, b, blackPoint, whitePoint float64) (float64, float64, float64) {
+	r = AdjustBlackPoint(r, blackPoint)
+	g = AdjustBlackPoint(g, blackPoint)
+	b = AdjustBlackPoint(b, blackPoint)
 
-		r = AdjustWhitePoint(r, e.WhitePoint)
-		g = AdjustWhitePoint(g, e.WhitePoint)
-		b = AdjustWhitePoint(b, e.WhitePoint)
+	r = AdjustWhitePoint(r, whitePoint)
+	g = AdjustWhitePoint(g, whitePoint)
+	b = AdjustWhitePoint(b, whitePoint)
 
-		r = (r-0.5)*e.Contrast + 0.5
-		g = (g-0.5)*e.Contrast + 0.5
-		b = (b-0.5)*e.Contrast + 0.5
+	r = (r-0.5)*e.Contrast + 0.5
+	g = (g-0.5)*e.Contrast + 0.5
+	b = (b-0.5)*e.Contrast + 0.5
 
-		avg := (r + g + b) / 3.0
-		r = avg + (r-avg)*e.Saturation
-		g = avg + (g-avg)*e.Saturation
-		b = avg + (b-avg)*e.Saturation
+	avg := (r + g + b) / 3.0
+	r = avg + (r-avg)*e.Saturation
+	g = avg + (g-avg)*e.Saturation
+	b = avg + (b-avg)*e.Saturation
 
-		r = AdjustBrightness(r, e.Brightness)
-		g = AdjustBrightness(g, e.Brightness)
-		b = AdjustBrightness(b, e.Brightness)
+	r = AdjustBrightness(r, e.Brightness)
+	g = AdjustBrightness(g, e.Brightness)
+	b = AdjustBrightness(b, e.Brightness)
 
+	// The rotation matrix is not exactly the identity at zero degrees
+	if e.Hue != 0 {
 		r, g, b = RotateHue(r, g, b, e.Hue)
+	}
 
-		r = ApplyGamma(r, e.Gamma)
-		g = ApplyGamma(g, e.Gamma)
-		b = ApplyGamma(b, e.Gamma)
+	// Negative values would turn into NaN under fractional gamma
+	r = ApplyGamma(max(r, 0), e.Gamma)
+	g = ApplyGamma(max(g, 0), e.Gamma)
+	b = ApplyGamma(max(b, 0), e.Gamma)
 
-		r = AdjustShadowStrength(r, e.ShadowStrength)
-		g = AdjustShadowStrength(g, e.ShadowStrength)
-		b = AdjustShadowStrength(b, e.ShadowStrength)
+	r = AdjustShadowStrength(r, e.ShadowStrength)
+	g = AdjustShadowStrength(g, e.ShadowStrength)
+	b = AdjustShadowStrength(b, e.ShadowStrength)
 
-		r = Clamp(r, 0, 1)
-		g = Clamp(g, 0, 1)
-		b = Clamp(b, 0, 1)
+	return Clamp(r, 0, 1), Clamp(g, 0, 1), Clamp(b, 0, 1)
+}
 
-		return color.NRGBA{
-			R: uint8(r * 255),
-			G: uint8(g * 255),
-			B: uint8(b * 255),
-			A: c.A,
+// ApplyEnhancements runs all configured adjustments in 8-bit precision.
+// Per-pixel stages are compiled into lookup tables, which are reused while
+// settings stay the same.
+func (e *ImageEnhancer) ApplyEnhancements(img image.Image) image.Image {
+	gains := e.whiteBalanceGains(img)
+
+	// CLAHE and auto levels analyze the white balanced image, otherwise gains fold into the tables
+	if e.CLAHEClipLimit > 0 || e.AutoLevels {
+		img = applyGains(img, gains)
+		gains = WhiteBalanceGains{1, 1, 1}
+	}
+
+	if e.CLAHEClipLimit > 0 {
+		img = ApplyCLAHE(img, e.CLAHEClipLimit, e.CLAHETiles)
+	}
+
+	blackPoint, whitePoint := e.levels(img)
+
+	dst := imaging.Clone(img)
+	e.compile(gains, blackPoint, whitePoint).apply(e, dst)
+
+	var result image.Image = dst
+	if len(e.HSLAdjustments) > 0 {
+		result = ApplyHSL(result, e.HSLAdjustments)
+	}
+
+	return result
+}
+
+// ApplyEnhancements64 runs the same adjustments as ApplyEnhancements but keeps
+// 16 bits per channel, so later steps and dithering avoid banding. Analysis
+// steps such as auto white balance, auto levels and CLAHE work on 8-bit data.
+func (e *ImageEnhancer) ApplyEnhancements64(img image.Image) *image.NRGBA64 {
+	gains := e.whiteBalanceGains(img)
+	if e.CLAHEClipLimit > 0 {
+		img = ApplyCLAHE(applyGains(img, gains), e.CLAHEClipLimit, e.CLAHETiles)
+		gains = WhiteBalanceGains{1, 1, 1}
+	}
+
+	blackPoint, whitePoint := e.BlackPoint, e.WhitePoint
+	if e.AutoLevels {
+		blackPoint, whitePoint = e.levels(applyGains(img, gains))
+	}
+
+	var curves *toneCurveSamples
+	if !e.ToneCurve.Empty() {
+		curves = e.ToneCurve.samples()
+	}
+	var hslTable *[360]HSLShift
+	if len(e.HSLAdjustments) > 0 {
+		table := hslShiftTable(e.HSLAdjustments)
+		hslTable = &table
+	}
+
+	dst := toNRGBA64Copy(img)
+	for i := 0; i < len(dst.Pix); i += 8 {
+		r := float64(uint16(dst.Pix[i])<<8|uint16(dst.Pix[i+1])) / 65535
+		g := float64(uint16(dst.Pix[i+2])<<8|uint16(dst.Pix[i+3])) / 65535
+		b := float64(uint16(dst.Pix[i+4])<<8|uint16(dst.Pix[i+5])) / 65535
+
+		if e.LinearLight {
+			r, g, b = e.adjustLinear(r, g, b, gains, blackPoint, whitePoint)
+		} else {
+			r, g, b = e.adjustPixel(Clamp(r*gains[0], 0, 1), Clamp(g*gains[1], 0, 1), Clamp(b*gains[2], 0, 1), blackPoint, whitePoint)
 		}
-	})
+		if curves != nil {
+			r, g, b = curves.apply(r, g, b)
+		}
+		if hslTable != nil {
+			r, g, b = shiftHSL(r, g, b, hslTable)
+		}
+
+		for c, value := range [3]float64{r, g, b} {
+			v := uint16(Clamp(value, 0, 1)*65535 + 0.5)
+			dst.Pix[i+c*2] = uint8(v >> 8)
+			dst.Pix[i+c*2+1] = uint8(v)
+		}
+	}
+
+	return dst
+}
+
+// adjustLinear decodes sRGB values to linear light, applies gains and global
+// adjustments and encodes the result again
+func (e *ImageEnhancer) adjustLinear(r, g, b float64, gains WhiteBalanceGains, blackPoint, whitePoint float64) (float64, float64, float64) {
+	r = Clamp(postprocess.SRGBToLinear(r)*gains[0], 0, 1)
+	g = Clamp(postprocess.SRGBToLinear(g)*gains[1], 0, 1)
+	b = Clamp(postprocess.SRGBToLinear(b)*gains[2], 0, 1)
+
+	r, g, b = e.adjustPixel(r, g, b, blackPoint, whitePoint)
+	return postprocess.LinearToSRGB(r), postprocess.LinearToSRGB(g), postprocess.LinearToSRGB(b)
+}
+
+// toNRGBA64Copy converts img into a new 16-bit image with bounds starting at zero
+func toNRGBA64Copy(img image.Image) *image.NRGBA64 {
+	bounds := img.Bounds()
+	dst := image.NewNRGBA64(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
 }
@@ -6,6 +6,7 @@ package adjustment
 
 import (
 	"image"
+	"image/color"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -121,3 +122,32 @@ func TestImageEnhancerApplyEnhancements(t *testing.T) {
 	assert.NotNil(t, enhancedImg, "ApplyEnhancements should return a non-nil image")
 	assert.Equal(t, img.Bounds(), enhancedImg.Bounds(), "Enhanced image should have same dimensions as original image")
 }
+
+func TestImageEnhancerApplyEnhancements64(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 4), G: 128, B: uint8(255 - x*4), A: 255})
+		}
+	}
+
+	enhancer := NewImageEnhancer()
+	enhancer.Contrast = 1.2
+	enhancer.Gamma = 0.9
+	enhancer.ToneCurve = ToneCurve{Master: []CurvePoint{{0, 0}, {0.5, 0.55}, {1, 1}}}
+	enhancer.HSLAdjustments = map[string]HSLShift{"blues": {Saturation: -0.3}}
+
+	low := enhancer.ApplyEnhancements(img)
+	high := enhancer.ApplyEnhancements64(img)
+	assert.Equal(t, img.Bounds(), high.Bounds(), "High precision image should have same dimensions as original image")
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 64; x++ {
+			r8, g8, b8, _ := low.At(x, y).RGBA()
+			r16, g16, b16, _ := high.At(x, y).RGBA()
+			assert.InDelta(t, float64(r8), float64(r16), 2*257, "Red should match the 8-bit path at %d,%d", x, y)
+			assert.InDelta(t, float64(g8), float64(g16), 2*257, "Green should match the 8-bit path at %d,%d", x, y)
+			assert.InDelta(t, float64(b8), float64(b16), 2*257, "Blue should match the 8-bit path at %d,%d", x, y)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	DefaultCLAHETiles     = 8
+	DefaultAutoLevelsClip = 0.005
+)
+
+// luminance returns Rec. 601 luma of an 8-bit RGB triple
+func luminance(r, g, b uint8) uint8 {
+	return uint8((299*int(r) + 587*int(g) + 114*int(b) + 500) / 1000)
+}
+
+// LuminanceHistogram counts luma values of all pixels
+func LuminanceHistogram(img image.Image) [256]int {
+	src := imaging.Clone(img)
+
+	var histogram [256]int
+	for i := 0; i < len(src.Pix); i += 4 {
+		histogram[luminance(src.Pix[i], src.Pix[i+1], src.Pix[i+2])]++
+	}
+	return histogram
+}
+
+// AutoLevels picks black and white points so that clip fraction of pixels
+// saturates at either end of the luminance histogram. Returned values are
+// in [0, 1] on the input scale.
+func AutoLevels(img image.Image, clip float64) (float64, float64) {
+	histogram := LuminanceHistogram(img)
+
+	total := 0
+	for _, count := range histogram {
+		total += count
+	}
+	if total == 0 {
+		return 0, 1
+	}
+
+	limit := int(clip * float64(total))
+
+	low, sum := 0, 0
+	for ; low < 255; low++ {
+		sum += histogram[low]
+		if sum > limit {
+			break
+		}
+	}
+
+	high := 255
+	sum = 0
+	for ; high > 0; high-- {
+		sum += histogram[high]
+		if sum > limit {
+			break
+		}
+	}
+
+	if high <= low {
+		return 0, 1
+	}
+	return float64(low) / 255.0, float64(high) / 255.0
+}
+
+// ApplyCLAHE performs contrast limited adaptive histogram equalization on
+// luminance. The image is split into tiles x tiles regions, each equalized
+// with a histogram clipped at clipLimit times the average bin height, and
+// mappings are bilinearly interpolated between tile centers. Chroma is kept by
+// scaling RGB with the luminance ratio.
+func ApplyCLAHE(img image.Image, clipLimit float64, tiles int) *image.NRGBA {
+	src := imaging.Clone(img)
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	if width == 0 || height == 0 || clipLimit <= 0 {
+		return src
+	}
+
+	if tiles <= 0 {
+		tiles = DefaultCLAHETiles
+	}
+	tilesX := min(tiles, width)
+	tilesY := min(tiles, height)
+
+	luma := make([]uint8, width*height)
+	for i := range luma {
+		luma[i] = luminance(src.Pix[i*4], src.Pix[i*4+1], src.Pix[i*4+2])
+	}
+
+	mappings := make([][256]uint8, tilesX*tilesY)
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			x0, x1 := tx*width/tilesX, (tx+1)*width/tilesX
+			y0, y1 := ty*height/tilesY, (ty+1)*height/tilesY
+
+			var histogram [256]int
+			for y := y0; y < y1; y++ {
+				for _, v := range luma[y*width+x0 : y*width+x1] {
+					histogram[v]++
+				}
+			}
+			mappings[ty*tilesX+tx] = equalizationMap(histogram, (x1-x0)*(y1-y0), clipLimit)
+		}
+	}
+
+	tileWidth := float64(width) / float64(tilesX)
+	tileHeight := float64(height) / float64(tilesY)
+
+	for y := 0; y < height; y++ {
+		ty0, ty1, wy := tileNeighbors(y, tileHeight, tilesY)
+		for x := 0; x < width; x++ {
+			tx0, tx1, wx := tileNeighbors(x, tileWidth, tilesX)
+
+			v := luma[y*width+x]
+			top := (1-wx)*float64(mappings[ty0*tilesX+tx0][v]) + wx*float64(mappings[ty0*tilesX+tx1][v])
+			bottom := (1-wx)*float64(mappings[ty1*tilesX+tx0][v]) + wx*float64(mappings[ty1*tilesX+tx1][v])
+			mapped := (1-wy)*top + wy*bottom
+
+			i := (y*width + x) * 4
+			if v == 0 {
+				value := uint8(Clamp(mapped+0.5, 0, 255))
+				src.Pix[i], src.Pix[i+1], src.Pix[i+2] = value, value, value
+				continue
+			}
+
+			ratio := mapped / float64(v)
+			src.Pix[i] = uint8(Clamp(float64(src.Pix[i])*ratio+0.5, 0, 255))
+			src.Pix[i+1] = uint8(Clamp(float64(src.Pix[i+1])*ratio+0.5, 0, 255))
+			src.Pix[i+2] = uint8(Clamp(float64(src.Pix[i+2])*ratio+0.5, 0, 255))
+		}
+	}
+
+	return src
+}
+
+// equalizationMap clips histogram, redistributes the excess evenly and returns
+// the resulting cumulative mapping
+func equalizationMap(histogram [256]int, pixels int, clipLimit float64) [256]uint8 {
+	var mapping [256]uint8
+	if pixels == 0 {
+		for i := range mapping {
+			mapping[i] = uint8(i)
+		}
+		return mapping
+	}
+
+	limit := max(1, int(clipLimit*float64(pixels)/256.0))
+	excess := 0
+	for i, count := range histogram {
+		if count > limit {
+			excess += count - limit
+			histogram[i] = limit
+		}
+	}
+
+	bonus, remainder := excess/256, excess%256
+	for i := range histogram {
+		histogram[i] += bonus
+		if i < remainder {
+			histogram[i]++
+		}
+	}
+
+	sum := 0
+	for i, count := range histogram {
+		sum += count
+		mapping[i] = uint8(Clamp(float64(sum)*255.0/float64(pixels)+0.5, 0, 255))
+	}
+	return mapping
+}
+
+// tileNeighbors returns the two tile indices whose centers surround position
+// and the interpolation weight of the second one
+func tileNeighbors(position int, tileSize float64, tiles int) (int, int, float64) {
+	center := (float64(position)+0.5)/tileSize - 0.5
+	if center <= 0 {
+		return 0, 0, 0
+	}
+	if center >= float64(tiles-1) {
+		return tiles - 1, tiles - 1, 0
+	}
+
+	first := int(center)
+	return first, first + 1, center - float64(first)
+}
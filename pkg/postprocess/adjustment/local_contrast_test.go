@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hazyImage returns a low contrast horizontal gradient between 100 and 140
+func hazyImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(100 + x*40/width)
+			img.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+func lumaRange(img image.Image) (uint8, uint8) {
+	histogram := LuminanceHistogram(img)
+	low, high := uint8(255), uint8(0)
+	for v, count := range histogram {
+		if count > 0 {
+			low = min(low, uint8(v))
+			high = max(high, uint8(v))
+		}
+	}
+	return low, high
+}
+
+func TestAutoLevels(t *testing.T) {
+	low, high := AutoLevels(hazyImage(100, 10), 0.01)
+
+	assert.InDelta(t, 100.0/255.0, low, 0.01, "Black point should match darkest values")
+	assert.InDelta(t, 139.0/255.0, high, 0.01, "White point should match brightest values")
+
+	low, high = AutoLevels(image.NewNRGBA(image.Rect(0, 0, 10, 10)), 0.01)
+	assert.Equal(t, 0.0, low, "Flat image should keep default black point")
+	assert.Equal(t, 1.0, high, "Flat image should keep default white point")
+}
+
+func TestApplyCLAHE(t *testing.T) {
+	img := hazyImage(128, 64)
+	result := ApplyCLAHE(img, 4.0, 4)
+
+	assert.Equal(t, img.Bounds(), result.Bounds(), "CLAHE should keep dimensions")
+
+	inLow, inHigh := lumaRange(img)
+	outLow, outHigh := lumaRange(result)
+	assert.Greater(t, int(outHigh)-int(outLow), int(inHigh)-int(inLow), "CLAHE should increase local contrast")
+
+	unchanged := ApplyCLAHE(img, 0, 4)
+	assert.Equal(t, img.Pix, unchanged.Pix, "Zero clip limit should leave the image untouched")
+}
+
+func TestImageEnhancerAutoLevels(t *testing.T) {
+	enhancer := NewImageEnhancer()
+	enhancer.AutoLevels = true
+
+	low, high := lumaRange(enhancer.ApplyEnhancements(hazyImage(100, 10)))
+	assert.LessOrEqual(t, low, uint8(5), "Auto levels should stretch shadows to black")
+	assert.GreaterOrEqual(t, high, uint8(245), "Auto levels should stretch highlights to white")
+}
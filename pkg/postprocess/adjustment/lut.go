@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	maxLUTSize = 256
+)
+
+// LUT3D is a 3D color lookup table as stored in Adobe/Resolve .cube files.
+// Table is indexed with red varying fastest: r + g*Size + b*Size*Size.
+type LUT3D struct {
+	Title     string
+	Size      int
+	DomainMin [3]float64
+	DomainMax [3]float64
+	Table     [][3]float64
+}
+
+// LoadCube reads a .cube 3D LUT file
+func LoadCube(path string) (*LUT3D, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open LUT: %w", err)
+	}
+	defer file.Close()
+
+	lut, err := ParseCube(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse LUT %s: %w", filepath.Base(path), err)
+	}
+	return lut, nil
+}
+
+// ParseCube parses .cube 3D LUT data. 1D LUTs are not supported.
+func ParseCube(r io.Reader) (*LUT3D, error) {
+	lut := &LUT3D{DomainMax: [3]float64{1, 1, 1}}
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "TITLE":
+			lut.Title = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "TITLE")), `"`)
+		case "LUT_1D_SIZE":
+			return nil, fmt.Errorf("1D LUTs are not supported")
+		case "LUT_3D_SIZE":
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: invalid LUT_3D_SIZE", lineNumber)
+			}
+			size, err := strconv.Atoi(fields[1])
+			if err != nil || size < 2 || size > maxLUTSize {
+				return nil, fmt.Errorf("line %d: invalid LUT_3D_SIZE %q", lineNumber, fields[1])
+			}
+			lut.Size = size
+			lut.Table = make([][3]float64, 0, size*size*size)
+		case "DOMAIN_MIN", "DOMAIN_MAX":
+			values, err := parseTriple(fields[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			if fields[0] == "DOMAIN_MIN" {
+				lut.DomainMin = values
+			} else {
+				lut.DomainMax = values
+			}
+		default:
+			if lut.Size == 0 {
+				return nil, fmt.Errorf("line %d: table data before LUT_3D_SIZE", lineNumber)
+			}
+			values, err := parseTriple(fields)
+			if err != nil {
+				// Unknown keywords are skipped as recommended by the format specification
+				if _, numErr := strconv.ParseFloat(fields[0], 64); numErr != nil {
+					continue
+				}
+				return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+			}
+			lut.Table = append(lut.Table, values)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if lut.Size == 0 {
+		return nil, fmt.Errorf("missing LUT_3D_SIZE")
+	}
+	if expected := lut.Size * lut.Size * lut.Size; len(lut.Table) != expected {
+		return nil, fmt.Errorf("expected %d table entries, got %d", expected, len(lut.Table))
+	}
+	for i := 0; i < 3; i++ {
+		if lut.DomainMax[i] <= lut.DomainMin[i] {
+			return nil, fmt.Errorf("invalid domain")
+		}
+	}
+
+	return lut, nil
+}
+
+func parseTriple(fields []string) ([3]float64, error) {
+	var values [3]float64
+	if len(fields) != 3 {
+		return values, fmt.Errorf("expected 3 values, got %d", len(fields))
+	}
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return values, fmt.Errorf("invalid value %q", field)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// Lookup maps a color in [0, 1] through the LUT with trilinear interpolation
+func (l *LUT3D) Lookup(r, g, b float64) (float64, float64, float64) {
+	maxIndex := float64(l.Size - 1)
+	position := func(value float64, channel int) (int, int, float64) {
+		scaled := (value - l.DomainMin[channel]) / (l.DomainMax[channel] - l.DomainMin[channel])
+		scaled = Clamp(scaled, 0, 1) * maxIndex
+		low := int(scaled)
+		if low >= l.Size-1 {
+			return l.Size - 1, l.Size - 1, 0
+		}
+		return low, low + 1, scaled - float64(low)
+	}
+
+	r0, r1, fr := position(r, 0)
+	g0, g1, fg := position(g, 1)
+	b0, b1, fb := position(b, 2)
+
+	at := func(ri, gi, bi int) [3]float64 {
+		return l.Table[ri+gi*l.Size+bi*l.Size*l.Size]
+	}
+
+	var result [3]float64
+	for c := 0; c < 3; c++ {
+		c00 := at(r0, g0, b0)[c]*(1-fr) + at(r1, g0, b0)[c]*fr
+		c10 := at(r0, g1, b0)[c]*(1-fr) + at(r1, g1, b0)[c]*fr
+		c01 := at(r0, g0, b1)[c]*(1-fr) + at(r1, g0, b1)[c]*fr
+		c11 := at(r0, g1, b1)[c]*(1-fr) + at(r1, g1, b1)[c]*fr
+
+		c0 := c00*(1-fg) + c10*fg
+		c1 := c01*(1-fg) + c11*fg
+		result[c] = c0*(1-fb) + c1*fb
+	}
+
+	return result[0], result[1], result[2]
+}
+
+// Apply grades img through the LUT and mixes the result with the original by strength
+func (l *LUT3D) Apply(img image.Image, strength float64) *image.NRGBA {
+	strength = Clamp(strength, 0, 1)
+	dst := imaging.Clone(img)
+
+	for i := 0; i < len(dst.Pix); i += 4 {
+		r := float64(dst.Pix[i]) / 255.0
+		g := float64(dst.Pix[i+1]) / 255.0
+		b := float64(dst.Pix[i+2]) / 255.0
+
+		lr, lg, lb := l.Lookup(r, g, b)
+
+		dst.Pix[i] = uint8(Clamp(r+(lr-r)*strength, 0, 1)*255 + 0.5)
+		dst.Pix[i+1] = uint8(Clamp(g+(lg-g)*strength, 0, 1)*255 + 0.5)
+		dst.Pix[i+2] = uint8(Clamp(b+(lb-b)*strength, 0, 1)*255 + 0.5)
+	}
+
+	return dst
+}
+
+// Apply64 blends img towards the LUT output keeping 16 bits per channel
+func (l *LUT3D) Apply64(img image.Image, strength float64) *image.NRGBA64 {
+	strength = Clamp(strength, 0, 1)
+	dst := toNRGBA64Copy(img)
+
+	for i := 0; i < len(dst.Pix); i += 8 {
+		var rgb [3]float64
+		for c := range rgb {
+			rgb[c] = float64(uint16(dst.Pix[i+c*2])<<8|uint16(dst.Pix[i+c*2+1])) / 65535
+		}
+
+		lr, lg, lb := l.Lookup(rgb[0], rgb[1], rgb[2])
+		for c, value := range [3]float64{lr, lg, lb} {
+			v := uint16(Clamp(rgb[c]+(value-rgb[c])*strength, 0, 1)*65535 + 0.5)
+			dst.Pix[i+c*2] = uint8(v >> 8)
+			dst.Pix[i+c*2+1] = uint8(v)
+		}
+	}
+
+	return dst
+}
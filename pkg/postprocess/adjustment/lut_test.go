@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cubeData builds a .cube LUT of given size from a color transform
+func cubeData(size int, transform func(r, g, b float64) (float64, float64, float64)) string {
+	var builder strings.Builder
+	builder.WriteString("# Test LUT\nTITLE \"test\"\n")
+	fmt.Fprintf(&builder, "LUT_3D_SIZE %d\n", size)
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				tr, tg, tb := transform(float64(r)/float64(size-1), float64(g)/float64(size-1), float64(b)/float64(size-1))
+				fmt.Fprintf(&builder, "%f %f %f\n", tr, tg, tb)
+			}
+		}
+	}
+	return builder.String()
+}
+
+func TestParseCube(t *testing.T) {
+	lut, err := ParseCube(strings.NewReader(cubeData(3, func(r, g, b float64) (float64, float64, float64) { return r, g, b })))
+	require.NoError(t, err, "Valid cube should parse")
+	assert.Equal(t, "test", lut.Title, "Title should be parsed")
+	assert.Equal(t, 3, lut.Size, "Size should be parsed")
+	assert.Len(t, lut.Table, 27, "Table should contain size^3 entries")
+
+	_, err = ParseCube(strings.NewReader("LUT_3D_SIZE 2\n0 0 0\n"))
+	assert.Error(t, err, "Truncated table should fail")
+
+	_, err = ParseCube(strings.NewReader("LUT_1D_SIZE 2\n0 0 0\n1 1 1\n"))
+	assert.Error(t, err, "1D LUTs should be rejected")
+}
+
+func TestLUT3DLookup(t *testing.T) {
+	invert, err := ParseCube(strings.NewReader(cubeData(5, func(r, g, b float64) (float64, float64, float64) { return 1 - r, 1 - g, 1 - b })))
+	require.NoError(t, err, "Cube should parse")
+
+	r, g, b := invert.Lookup(0.3, 0.55, 0.9)
+	assert.InDelta(t, 0.7, r, 0.0001, "Red should be interpolated between grid points")
+	assert.InDelta(t, 0.45, g, 0.0001, "Green should be interpolated between grid points")
+	assert.InDelta(t, 0.1, b, 0.0001, "Blue should be interpolated between grid points")
+}
+
+func TestLUT3DApply(t *testing.T) {
+	invert, err := ParseCube(strings.NewReader(cubeData(2, func(r, g, b float64) (float64, float64, float64) { return 1 - r, 1 - g, 1 - b })))
+	require.NoError(t, err, "Cube should parse")
+
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.SetNRGBA(0, 0, color.NRGBA{255, 0, 51, 200})
+
+	full := invert.Apply(img, 1)
+	assert.Equal(t, color.NRGBA{0, 255, 204, 200}, full.NRGBAAt(0, 0), "Full strength should apply LUT and keep alpha")
+
+	half := invert.Apply(img, 0.5)
+	assert.InDelta(t, 128, int(half.NRGBAAt(0, 0).R), 1, "Half strength should mix with original")
+
+	none := invert.Apply(img, 0)
+	assert.Equal(t, img.NRGBAAt(0, 0), none.NRGBAAt(0, 0), "Zero strength should keep original")
+}
+
+func TestLUT3DApply64(t *testing.T) {
+	invert, err := ParseCube(strings.NewReader(cubeData(2, func(r, g, b float64) (float64, float64, float64) { return 1 - r, 1 - g, 1 - b })))
+	require.NoError(t, err, "Cube should parse")
+
+	img := image.NewNRGBA64(image.Rect(0, 0, 2, 2))
+	img.SetNRGBA64(0, 0, color.NRGBA64{R: 65535, G: 1000, B: 20000, A: 50000})
+
+	full := invert.Apply64(img, 1)
+	assert.Equal(t, color.NRGBA64{R: 0, G: 64535, B: 45535, A: 50000}, full.NRGBA64At(0, 0), "Full strength should apply LUT in 16 bits and keep alpha")
+}
@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	WhiteBalanceGrayWorld  = "gray_world"
+	WhiteBalanceWhitePatch = "white_patch"
+
+	// ReferenceKelvin is the neutral color temperature, daylight D65
+	ReferenceKelvin = 6500.0
+
+	whitePatchPercentile = 0.99
+	minWhiteBalanceGain  = 0.5
+	maxWhiteBalanceGain  = 2.0
+	relativeShiftScale   = 0.3
+)
+
+// WhiteBalanceGains are per channel multipliers
+type WhiteBalanceGains [3]float64
+
+// ValidateWhiteBalance checks the auto white balance mode
+func ValidateWhiteBalance(mode string) error {
+	switch mode {
+	case "", WhiteBalanceGrayWorld, WhiteBalanceWhitePatch:
+		return nil
+	default:
+		return fmt.Errorf("unknown auto white balance mode: %q", mode)
+	}
+}
+
+// KelvinToRGB approximates the color of a black body radiator at the given
+// temperature (Tanner Helland's fit), channels in [0, 1]
+func KelvinToRGB(kelvin float64) (float64, float64, float64) {
+	t := Clamp(kelvin, 1000, 40000) / 100
+
+	var r, g, b float64
+	if t <= 66 {
+		r = 255
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	} else {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	}
+
+	switch {
+	case t >= 66:
+		b = 255
+	case t <= 19:
+		b = 0
+	default:
+		b = 138.5177312231*math.Log(t-10) - 305.0447927307
+	}
+
+	return Clamp(r, 0, 255) / 255, Clamp(g, 0, 255) / 255, Clamp(b, 0, 255) / 255
+}
+
+// KelvinGains neutralises a scene lit at kelvin towards daylight. Low values
+// cool down warm tungsten light, high values warm up blue shade.
+func KelvinGains(kelvin float64) WhiteBalanceGains {
+	refR, refG, refB := KelvinToRGB(ReferenceKelvin)
+	r, g, b := KelvinToRGB(kelvin)
+	return WhiteBalanceGains{refR / math.Max(r, 0.01), refG / math.Max(g, 0.01), refB / math.Max(b, 0.01)}
+}
+
+// RelativeGains shifts along the blue-amber axis by temperature and the
+// green-magenta axis by tint, both in [-1, 1]. Positive values warm and add magenta.
+func RelativeGains(temperature, tint float64) WhiteBalanceGains {
+	return WhiteBalanceGains{
+		1 + relativeShiftScale*temperature,
+		1 - relativeShiftScale*tint,
+		1 - relativeShiftScale*temperature,
+	}
+}
+
+// AutoWhiteBalanceGains estimates gains that neutralise the image's color
+// cast. Gray world assumes the average color is gray, white patch assumes the
+// brightest tones are white.
+func AutoWhiteBalanceGains(img image.Image, mode string) WhiteBalanceGains {
+	src := imaging.Clone(img)
+	pixels := len(src.Pix) / 4
+	if pixels == 0 {
+		return WhiteBalanceGains{1, 1, 1}
+	}
+
+	var reference [3]float64
+	switch mode {
+	case WhiteBalanceGrayWorld:
+		var sums [3]float64
+		for i := 0; i < len(src.Pix); i += 4 {
+			sums[0] += float64(src.Pix[i])
+			sums[1] += float64(src.Pix[i+1])
+			sums[2] += float64(src.Pix[i+2])
+		}
+		for c := range reference {
+			reference[c] = sums[c] / float64(pixels)
+		}
+	case WhiteBalanceWhitePatch:
+		var histograms [3][256]int
+		for i := 0; i < len(src.Pix); i += 4 {
+			histograms[0][src.Pix[i]]++
+			histograms[1][src.Pix[i+1]]++
+			histograms[2][src.Pix[i+2]]++
+		}
+		target := int(whitePatchPercentile * float64(pixels))
+		for c := range reference {
+			sum := 0
+			for v, count := range histograms[c] {
+				sum += count
+				if sum >= target {
+					reference[c] = float64(v)
+					break
+				}
+			}
+		}
+	default:
+		return WhiteBalanceGains{1, 1, 1}
+	}
+
+	gray := (reference[0] + reference[1] + reference[2]) / 3
+	var gains WhiteBalanceGains
+	for c := range gains {
+		gains[c] = gray / math.Max(reference[c], 1)
+	}
+	return gains
+}
+
+// Multiply combines two sets of gains
+func (g WhiteBalanceGains) Multiply(other WhiteBalanceGains) WhiteBalanceGains {
+	return WhiteBalanceGains{g[0] * other[0], g[1] * other[1], g[2] * other[2]}
+}
+
+// Normalize limits gains to a sane range and rescales them so luminance of
+// neutral tones is preserved
+func (g WhiteBalanceGains) Normalize() WhiteBalanceGains {
+	luma := 0.299*g[0] + 0.587*g[1] + 0.114*g[2]
+	if luma <= 0 {
+		return WhiteBalanceGains{1, 1, 1}
+	}
+
+	var normalized WhiteBalanceGains
+	for c := range g {
+		normalized[c] = Clamp(g[c]/luma, minWhiteBalanceGain, maxWhiteBalanceGain)
+	}
+	return normalized
+}
+
+// Identity reports whether gains leave colors unchanged
+func (g WhiteBalanceGains) Identity() bool {
+	for _, gain := range g {
+		if math.Abs(gain-1) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package adjustment
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+// castImage returns a gray ramp with a warm color cast
+func castImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 64; x++ {
+			v := float64(40 + x*2)
+			img.SetNRGBA(x, y, color.NRGBA{uint8(v * 1.2), uint8(v), uint8(v * 0.75), 255})
+		}
+	}
+	return img
+}
+
+func channelMeans(img image.Image) [3]float64 {
+	src := imaging.Clone(img)
+	var sums [3]float64
+	for i := 0; i < len(src.Pix); i += 4 {
+		sums[0] += float64(src.Pix[i])
+		sums[1] += float64(src.Pix[i+1])
+		sums[2] += float64(src.Pix[i+2])
+	}
+	pixels := float64(len(src.Pix) / 4)
+	return [3]float64{sums[0] / pixels, sums[1] / pixels, sums[2] / pixels}
+}
+
+func TestKelvinGains(t *testing.T) {
+	neutral := KelvinGains(ReferenceKelvin)
+	assert.InDelta(t, 1.0, neutral[0], 0.0001, "Reference temperature should be neutral")
+	assert.InDelta(t, 1.0, neutral[2], 0.0001, "Reference temperature should be neutral")
+
+	tungsten := KelvinGains(3000)
+	assert.Greater(t, tungsten[2], tungsten[0], "Warm light should be corrected towards blue")
+
+	shade := KelvinGains(9000)
+	assert.Greater(t, shade[0], shade[2], "Cool light should be corrected towards red")
+}
+
+func TestRelativeGains(t *testing.T) {
+	warm := RelativeGains(0.5, 0)
+	assert.Greater(t, warm[0], warm[2], "Positive temperature should warm up")
+
+	magenta := RelativeGains(0, 0.5)
+	assert.Less(t, magenta[1], 1.0, "Positive tint should reduce green")
+}
+
+func TestAutoWhiteBalance(t *testing.T) {
+	for _, mode := range []string{WhiteBalanceGrayWorld, WhiteBalanceWhitePatch} {
+		enhancer := NewImageEnhancer()
+		enhancer.AutoWhiteBalance = mode
+
+		before := channelMeans(castImage())
+		after := channelMeans(enhancer.ApplyEnhancements(castImage()))
+
+		assert.Less(t, after[0]-after[2], before[0]-before[2], "Color cast should be reduced by %s", mode)
+		assert.InDelta(t, after[0], after[2], 6, "Red and blue should be close to neutral with %s", mode)
+	}
+
+	assert.NoError(t, ValidateWhiteBalance(WhiteBalanceGrayWorld), "Gray world should be valid")
+	assert.Error(t, ValidateWhiteBalance("daylight"), "Unknown mode should be invalid")
+}
+
+func TestWhiteBalanceGainsNormalize(t *testing.T) {
+	gains := WhiteBalanceGains{10, 1, 0.01}.Normalize()
+	for c := range gains {
+		assert.GreaterOrEqual(t, gains[c], minWhiteBalanceGain, "Gains should be clamped to minimum")
+		assert.LessOrEqual(t, gains[c], maxWhiteBalanceGain, "Gains should be clamped to maximum")
+	}
+	assert.True(t, WhiteBalanceGains{1, 1, 1}.Normalize().Identity(), "Identity gains should stay identity")
+}
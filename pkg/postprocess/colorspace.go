@@ -0,0 +1,270 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sync"
+)
+
+const (
+	ColorSpaceSRGB      = "srgb"
+	ColorSpaceDisplayP3 = "display_p3"
+	ColorSpaceAdobeRGB  = "adobe_rgb"
+
+	adobeRGBGamma = 563.0 / 256.0
+)
+
+// matrix3 is a row major 3x3 matrix
+type matrix3 [3][3]float64
+
+// chromaticities holds CIE xy coordinates of primaries and white point
+type chromaticities struct {
+	red, green, blue, white [2]float64
+}
+
+var (
+	d65 = [2]float64{0.3127, 0.3290}
+
+	// d50 is the ICC profile connection space illuminant
+	d50 = [3]float64{0.9642, 1.0, 0.8249}
+
+	colorSpaces = map[string]chromaticities{
+		ColorSpaceSRGB:      {red: [2]float64{0.640, 0.330}, green: [2]float64{0.300, 0.600}, blue: [2]float64{0.150, 0.060}, white: d65},
+		ColorSpaceDisplayP3: {red: [2]float64{0.680, 0.320}, green: [2]float64{0.265, 0.690}, blue: [2]float64{0.150, 0.060}, white: d65},
+		ColorSpaceAdobeRGB:  {red: [2]float64{0.640, 0.330}, green: [2]float64{0.210, 0.710}, blue: [2]float64{0.150, 0.060}, white: d65},
+	}
+
+	bradford = matrix3{
+		{0.8951, 0.2664, -0.1614},
+		{-0.7502, 1.7135, 0.0367},
+		{0.0389, -0.0685, 1.0296},
+	}
+
+	transferOnce   sync.Once
+	srgbDecode     []float32
+	adobeRGBDecode []float32
+	linearToSRGB   []uint16
+	srgbToLinear   []uint16
+)
+
+// ValidateColorSpace checks a color space name
+func ValidateColorSpace(name string) error {
+	if _, ok := colorSpaces[name]; !ok {
+		return fmt.Errorf("unknown color space: %q", name)
+	}
+	return nil
+}
+
+func (m matrix3) mul(n matrix3) matrix3 {
+	var result matrix3
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				result[i][j] += m[i][k] * n[k][j]
+			}
+		}
+	}
+	return result
+}
+
+func (m matrix3) apply(v [3]float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*v[0] + m[0][1]*v[1] + m[0][2]*v[2],
+		m[1][0]*v[0] + m[1][1]*v[1] + m[1][2]*v[2],
+		m[2][0]*v[0] + m[2][1]*v[1] + m[2][2]*v[2],
+	}
+}
+
+func (m matrix3) inverse() matrix3 {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+
+	return matrix3{
+		{(m[1][1]*m[2][2] - m[1][2]*m[2][1]) / det, (m[0][2]*m[2][1] - m[0][1]*m[2][2]) / det, (m[0][1]*m[1][2] - m[0][2]*m[1][1]) / det},
+		{(m[1][2]*m[2][0] - m[1][0]*m[2][2]) / det, (m[0][0]*m[2][2] - m[0][2]*m[2][0]) / det, (m[0][2]*m[1][0] - m[0][0]*m[1][2]) / det},
+		{(m[1][0]*m[2][1] - m[1][1]*m[2][0]) / det, (m[0][1]*m[2][0] - m[0][0]*m[2][1]) / det, (m[0][0]*m[1][1] - m[0][1]*m[1][0]) / det},
+	}
+}
+
+func xyToXYZ(xy [2]float64) [3]float64 {
+	return [3]float64{xy[0] / xy[1], 1, (1 - xy[0] - xy[1]) / xy[1]}
+}
+
+// rgbToXYZ returns the matrix from linear RGB to XYZ relative to the space's own white
+func (c chromaticities) rgbToXYZ() matrix3 {
+	r, g, b := xyToXYZ(c.red), xyToXYZ(c.green), xyToXYZ(c.blue)
+	primaries := matrix3{
+		{r[0], g[0], b[0]},
+		{r[1], g[1], b[1]},
+		{r[2], g[2], b[2]},
+	}
+
+	scale := primaries.inverse().apply(xyToXYZ(c.white))
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			primaries[i][j] *= scale[j]
+		}
+	}
+	return primaries
+}
+
+// adaptToD50 returns the Bradford chromatic adaptation from white to D50
+func adaptToD50(white [2]float64) matrix3 {
+	source := bradford.apply(xyToXYZ(white))
+	target := bradford.apply(d50)
+	scale := matrix3{
+		{target[0] / source[0], 0, 0},
+		{0, target[1] / source[1], 0},
+		{0, 0, target[2] / source[2]},
+	}
+	return bradford.inverse().mul(scale).mul(bradford)
+}
+
+// colorantsD50 returns the D50 adapted RGB to XYZ matrix as stored in ICC profiles
+func (c chromaticities) colorantsD50() matrix3 {
+	return adaptToD50(c.white).mul(c.rgbToXYZ())
+}
+
+// SRGBToLinear decodes an sRGB encoded value in [0, 1] to linear light
+func SRGBToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// LinearToSRGB encodes a linear light value in [0, 1] with the sRGB transfer curve
+func LinearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func transferTables() {
+	transferOnce.Do(func() {
+		srgbDecode = make([]float32, 65536)
+		adobeRGBDecode = make([]float32, 65536)
+		linearToSRGB = make([]uint16, 65536)
+		srgbToLinear = make([]uint16, 65536)
+		for i := range srgbDecode {
+			v := float64(i) / 65535
+			srgbDecode[i] = float32(SRGBToLinear(v))
+			adobeRGBDecode[i] = float32(math.Pow(v, adobeRGBGamma))
+			linearToSRGB[i] = uint16(LinearToSRGB(v)*65535 + 0.5)
+			srgbToLinear[i] = uint16(SRGBToLinear(v)*65535 + 0.5)
+		}
+	})
+}
+
+// mapChannels copies img into a 16-bit image, passing color channels through table
+func mapChannels(img image.Image, table []uint16) *image.NRGBA64 {
+	dst := copy64(img)
+
+	for i := 0; i < len(dst.Pix); i += 8 {
+		for c := 0; c < 3; c++ {
+			v := table[uint16(dst.Pix[i+c*2])<<8|uint16(dst.Pix[i+c*2+1])]
+			dst.Pix[i+c*2] = uint8(v >> 8)
+			dst.Pix[i+c*2+1] = uint8(v)
+		}
+	}
+	return dst
+}
+
+// ToLinear decodes sRGB encoded img into 16-bit linear light
+func ToLinear(img image.Image) *image.NRGBA64 {
+	transferTables()
+	return mapChannels(img, srgbToLinear)
+}
+
+// FromLinear encodes 16-bit linear light img with the sRGB transfer curve
+func FromLinear(img image.Image) *image.NRGBA64 {
+	transferTables()
+	return mapChannels(img, linearToSRGB)
+}
+
+// OverlayLinear draws img over background at pos with the given opacity,
+// mixing colors in linear light so blends do not darken
+func OverlayLinear(background, img image.Image, pos image.Point, opacity float64) *image.NRGBA64 {
+	transferTables()
+
+	dst := ToLinear(background)
+	src := ToLinear(img)
+	opacity = math.Min(math.Max(opacity, 0), 1)
+
+	area := src.Bounds().Add(pos).Intersect(dst.Bounds())
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := area.Min.X; x < area.Max.X; x++ {
+			i := dst.PixOffset(x, y)
+			j := src.PixOffset(x-pos.X, y-pos.Y)
+
+			srcAlpha := float64(uint16(src.Pix[j+6])<<8|uint16(src.Pix[j+7])) / 65535 * opacity
+			dstAlpha := float64(uint16(dst.Pix[i+6])<<8|uint16(dst.Pix[i+7])) / 65535
+			outAlpha := srcAlpha + dstAlpha*(1-srcAlpha)
+			if outAlpha == 0 {
+				continue
+			}
+
+			for c := 0; c < 4; c++ {
+				s := float64(uint16(src.Pix[j+c*2])<<8 | uint16(src.Pix[j+c*2+1]))
+				d := float64(uint16(dst.Pix[i+c*2])<<8 | uint16(dst.Pix[i+c*2+1]))
+				v := outAlpha * 65535
+				if c < 3 {
+					v = (s*srcAlpha + d*dstAlpha*(1-srcAlpha)) / outAlpha
+				}
+				out := uint16(math.Min(v+0.5, 65535))
+				dst.Pix[i+c*2] = uint8(out >> 8)
+				dst.Pix[i+c*2+1] = uint8(out)
+			}
+		}
+	}
+
+	return FromLinear(dst)
+}
+
+// ConvertColorSpace converts img between RGB color spaces. Colors outside the
+// target gamut are clipped.
+func ConvertColorSpace(img image.Image, from, to string) (*image.NRGBA64, error) {
+	source, ok := colorSpaces[from]
+	if !ok {
+		return nil, fmt.Errorf("unknown color space: %q", from)
+	}
+	target, ok := colorSpaces[to]
+	if !ok {
+		return nil, fmt.Errorf("unknown color space: %q", to)
+	}
+
+	transferTables()
+	decode := srgbDecode
+	if from == ColorSpaceAdobeRGB {
+		decode = adobeRGBDecode
+	}
+	encode := func(v float64) float64 { return LinearToSRGB(v) }
+	if to == ColorSpaceAdobeRGB {
+		encode = func(v float64) float64 { return math.Pow(v, 1/adobeRGBGamma) }
+	}
+
+	conversion := target.colorantsD50().inverse().mul(source.colorantsD50())
+
+	dst := copy64(img)
+	for i := 0; i < len(dst.Pix); i += 8 {
+		var linear [3]float64
+		for c := range linear {
+			linear[c] = float64(decode[uint16(dst.Pix[i+c*2])<<8|uint16(dst.Pix[i+c*2+1])])
+		}
+
+		for c, value := range conversion.apply(linear) {
+			v := uint16(encode(math.Min(math.Max(value, 0), 1))*65535 + 0.5)
+			dst.Pix[i+c*2] = uint8(v >> 8)
+			dst.Pix[i+c*2+1] = uint8(v)
+		}
+	}
+
+	return dst, nil
+}
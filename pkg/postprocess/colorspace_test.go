@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSRGBTransfer(t *testing.T) {
+	assert.InDelta(t, 0.214, SRGBToLinear(0.5), 0.001, "Mid gray should decode to about 21% linear light")
+	for _, v := range []float64{0, 0.01, 0.3, 0.7, 1} {
+		assert.InDelta(t, v, LinearToSRGB(SRGBToLinear(v)), 1e-9, "Transfer should round trip %v", v)
+	}
+}
+
+func TestLinearRoundTrip(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 256, 1))
+	for x := 0; x < 256; x++ {
+		img.SetNRGBA(x, 0, color.NRGBA{uint8(x), uint8(255 - x), 128, 255})
+	}
+
+	result := Dither(FromLinear(ToLinear(img)), DitherNone)
+	for x := 0; x < 256; x++ {
+		assert.InDelta(t, float64(img.NRGBAAt(x, 0).R), float64(result.NRGBAAt(x, 0).R), 1, "Linear round trip should keep value %d", x)
+	}
+}
+
+func TestOverlayLinear(t *testing.T) {
+	black := imaging.New(4, 4, color.NRGBA{0, 0, 0, 255})
+	white := imaging.New(4, 4, color.NRGBA{255, 255, 255, 255})
+
+	mixed := Dither(OverlayLinear(black, white, image.Pt(0, 0), 0.5), DitherNone)
+	assert.InDelta(t, 188, int(mixed.NRGBAAt(1, 1).R), 1, "Half mix of black and white should be half the light, not half the code value")
+	assert.Equal(t, uint8(255), mixed.NRGBAAt(1, 1).A, "Opaque background should stay opaque")
+}
+
+func TestConvertColorSpace(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{255, 0, 0, 255})
+	img.SetNRGBA(1, 0, color.NRGBA{128, 128, 128, 255})
+	img.SetNRGBA(2, 0, color.NRGBA{20, 160, 90, 255})
+
+	p3, err := ConvertColorSpace(img, ColorSpaceSRGB, ColorSpaceDisplayP3)
+	require.NoError(t, err, "Conversion should succeed")
+
+	red := p3.NRGBA64At(0, 0)
+	assert.Less(t, red.R, uint16(65535), "sRGB red should sit inside the wider P3 gamut")
+	assert.Greater(t, red.G, uint16(0), "sRGB red should contain some P3 green")
+
+	gray := p3.NRGBA64At(1, 0)
+	assert.InDelta(t, int(gray.R), int(gray.G), 64, "Neutral gray should stay neutral")
+	assert.InDelta(t, int(gray.G), int(gray.B), 64, "Neutral gray should stay neutral")
+
+	back, err := ConvertColorSpace(p3, ColorSpaceDisplayP3, ColorSpaceSRGB)
+	require.NoError(t, err, "Conversion back should succeed")
+	result := Dither(back, DitherNone)
+	for x := 0; x < 3; x++ {
+		want, got := img.NRGBAAt(x, 0), result.NRGBAAt(x, 0)
+		assert.InDelta(t, int(want.R), int(got.R), 1, "Red should round trip at %d", x)
+		assert.InDelta(t, int(want.G), int(got.G), 1, "Green should round trip at %d", x)
+		assert.InDelta(t, int(want.B), int(got.B), 1, "Blue should round trip at %d", x)
+	}
+
+	_, err = ConvertColorSpace(img, "prophoto", ColorSpaceSRGB)
+	assert.Error(t, err, "Unknown color spaces should be rejected")
+}
+
+func TestICCProfile(t *testing.T) {
+	for _, space := range []string{ColorSpaceSRGB, ColorSpaceDisplayP3, ColorSpaceAdobeRGB} {
+		profile, err := ICCProfile(space)
+		require.NoError(t, err, "Profile should build for %s", space)
+		assert.Equal(t, "acsp", string(profile[36:40]), "Profile should carry the ICC signature")
+		assert.Equal(t, space, ICCColorSpace(profile), "Profile colorants should identify %s", space)
+	}
+
+	// Published D50 adapted sRGB colorants
+	colorants := colorSpaces[ColorSpaceSRGB].colorantsD50()
+	assert.InDelta(t, 0.4361, colorants[0][0], 0.001, "sRGB red X should match the published value")
+	assert.InDelta(t, 0.7169, colorants[1][1], 0.001, "sRGB green Y should match the published value")
+	assert.InDelta(t, 0.7142, colorants[2][2], 0.001, "sRGB blue Z should match the published value")
+
+	assert.Empty(t, ICCColorSpace([]byte("not a profile")), "Garbage should not be identified")
+}
+
+func TestEmbedICCProfile(t *testing.T) {
+	img := imaging.New(8, 8, color.NRGBA{10, 20, 30, 255})
+	profile, err := ICCProfile(ColorSpaceDisplayP3)
+	require.NoError(t, err, "Profile should build")
+
+	for _, format := range []imaging.Format{imaging.PNG, imaging.JPEG} {
+		var buf bytes.Buffer
+		require.NoError(t, imaging.Encode(&buf, img, format), "Encoding should succeed")
+
+		tagged, err := EmbedICCProfile(buf.Bytes(), format, profile, ColorSpaceDisplayP3)
+		require.NoError(t, err, "Embedding should succeed for %s", format)
+
+		decoded, err := imaging.Decode(bytes.NewReader(tagged))
+		require.NoError(t, err, "Tagged %s should still decode", format)
+		assert.Equal(t, img.Bounds(), decoded.Bounds(), "Tagged %s should keep dimensions", format)
+	}
+
+	_, err = EmbedICCProfile([]byte("GIF89a"), imaging.GIF, profile, ColorSpaceDisplayP3)
+	assert.Error(t, err, "Unsupported formats should be rejected")
+}
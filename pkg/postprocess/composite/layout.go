@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package composite
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	LayoutGrid        = "grid"
+	LayoutPIP         = "pip"
+	LayoutSideBySide  = "side_by_side"
+	DefaultInsetScale = 0.25
+)
+
+var (
+	Background = color.NRGBA{0, 0, 0, 255}
+)
+
+// Grid arranges tiles row by row into equally sized cells. A columns value
+// below one picks a near square grid. Nil tiles leave their cell empty.
+// Tiles are scaled with filter.
+func Grid(width, height int, tiles []image.Image, columns, gap int, filter imaging.ResampleFilter) *image.NRGBA {
+	canvas := imaging.New(width, height, Background)
+	if len(tiles) == 0 {
+		return canvas
+	}
+
+	if columns < 1 {
+		columns = int(math.Ceil(math.Sqrt(float64(len(tiles)))))
+	}
+	rows := (len(tiles) + columns - 1) / columns
+
+	cellWidth := (width - gap*(columns-1)) / columns
+	cellHeight := (height - gap*(rows-1)) / rows
+	if cellWidth < 1 || cellHeight < 1 {
+		return canvas
+	}
+
+	for i, tile := range tiles {
+		if tile == nil {
+			continue
+		}
+		x := (i % columns) * (cellWidth + gap)
+		y := (i / columns) * (cellHeight + gap)
+		cell := imaging.Fill(tile, cellWidth, cellHeight, imaging.Center, filter)
+		draw.Draw(canvas, cell.Bounds().Add(image.Pt(x, y)), cell, image.Point{}, draw.Src)
+	}
+
+	return canvas
+}
+
+// PictureInPicture fills canvas with first tile and stacks remaining tiles as
+// insets along right edge. Insets keep canvas aspect ratio and are scaled to
+// insetScale of canvas width. Tiles are scaled with filter.
+func PictureInPicture(width, height int, tiles []image.Image, insetScale float64, margin int, filter imaging.ResampleFilter) *image.NRGBA {
+	canvas := imaging.New(width, height, Background)
+	if len(tiles) == 0 {
+		return canvas
+	}
+
+	if tiles[0] != nil {
+		main := imaging.Fill(tiles[0], width, height, imaging.Center, filter)
+		draw.Draw(canvas, canvas.Bounds(), main, image.Point{}, draw.Src)
+	}
+
+	if insetScale <= 0 || insetScale > 1 {
+		insetScale = DefaultInsetScale
+	}
+	insetWidth := int(float64(width) * insetScale)
+	insetHeight := int(float64(height) * insetScale)
+	if insetWidth < 1 || insetHeight < 1 {
+		return canvas
+	}
+
+	y := margin
+	for _, tile := range tiles[1:] {
+		if y+insetHeight > height {
+			break
+		}
+		if tile == nil {
+			continue
+		}
+		inset := imaging.Fill(tile, insetWidth, insetHeight, imaging.Center, filter)
+		x := width - insetWidth - margin
+		draw.Draw(canvas, inset.Bounds().Add(image.Pt(x, y)), inset, image.Point{}, draw.Src)
+		y += insetHeight + margin
+	}
+
+	return canvas
+}
+
+// SideBySide places tiles in equally wide columns and blends neighbouring
+// tiles over a seam of feather times canvas width. Tiles are scaled with
+// filter.
+func SideBySide(width, height int, tiles []image.Image, feather float64, filter imaging.ResampleFilter) *image.NRGBA {
+	canvas := imaging.New(width, height, Background)
+	if len(tiles) == 0 {
+		return canvas
+	}
+
+	columnWidth := width / len(tiles)
+	seam := int(math.Max(0, feather) * float64(width))
+	if seam > columnWidth {
+		seam = columnWidth
+	}
+
+	for i, tile := range tiles {
+		if tile == nil {
+			continue
+		}
+
+		left := i*columnWidth - seam/2
+		right := (i+1)*columnWidth + seam/2
+		if i == 0 {
+			left = 0
+		}
+		if i == len(tiles)-1 {
+			right = width
+		}
+
+		column := imaging.Fill(tile, right-left, height, imaging.Center, filter)
+		rect := column.Bounds().Add(image.Pt(left, 0))
+
+		if i == 0 || seam == 0 {
+			draw.Draw(canvas, rect, column, image.Point{}, draw.Src)
+			continue
+		}
+
+		draw.DrawMask(canvas, rect, column, image.Point{}, featherMask(right-left, height, seam), image.Point{}, draw.Over)
+	}
+
+	return canvas
+}
+
+// featherMask ramps from transparent to opaque over first seam pixels
+func featherMask(width, height, seam int) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		a := uint8(255)
+		if x < seam {
+			a = uint8(255 * float64(x) / float64(seam))
+		}
+		for y := 0; y < height; y++ {
+			mask.Pix[y*mask.Stride+x] = a
+		}
+	}
+	return mask
+}
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package composite
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrid(t *testing.T) {
+	red := imaging.New(40, 30, color.NRGBA{255, 0, 0, 255})
+	blue := imaging.New(40, 30, color.NRGBA{0, 0, 255, 255})
+
+	canvas := Grid(100, 50, []image.Image{red, blue}, 2, 0, imaging.Lanczos)
+
+	assert.Equal(t, image.Rect(0, 0, 100, 50), canvas.Bounds(), "Grid canvas should match target dimensions")
+	assert.Equal(t, color.NRGBA{255, 0, 0, 255}, canvas.NRGBAAt(10, 25), "First cell should contain first tile")
+	assert.Equal(t, color.NRGBA{0, 0, 255, 255}, canvas.NRGBAAt(90, 25), "Second cell should contain second tile")
+}
+
+func TestGridSkipsMissingTiles(t *testing.T) {
+	red := imaging.New(40, 30, color.NRGBA{255, 0, 0, 255})
+
+	canvas := Grid(100, 50, []image.Image{nil, red}, 2, 0, imaging.Lanczos)
+
+	assert.Equal(t, Background, canvas.NRGBAAt(10, 25), "Missing tile should leave background")
+	assert.Equal(t, color.NRGBA{255, 0, 0, 255}, canvas.NRGBAAt(90, 25), "Present tile should still be drawn")
+}
+
+func TestPictureInPicture(t *testing.T) {
+	red := imaging.New(160, 90, color.NRGBA{255, 0, 0, 255})
+	blue := imaging.New(160, 90, color.NRGBA{0, 0, 255, 255})
+
+	canvas := PictureInPicture(160, 90, []image.Image{red, blue}, 0.25, 5, imaging.Lanczos)
+
+	assert.Equal(t, color.NRGBA{255, 0, 0, 255}, canvas.NRGBAAt(10, 80), "Main view should fill canvas")
+	assert.Equal(t, color.NRGBA{0, 0, 255, 255}, canvas.NRGBAAt(150, 10), "Inset should be drawn top right")
+}
+
+func TestSideBySideFeather(t *testing.T) {
+	black := imaging.New(50, 50, color.NRGBA{0, 0, 0, 255})
+	white := imaging.New(50, 50, color.NRGBA{255, 255, 255, 255})
+
+	canvas := SideBySide(100, 50, []image.Image{black, white}, 0.2, imaging.Lanczos)
+
+	assert.Equal(t, uint8(0), canvas.NRGBAAt(5, 25).R, "Left edge should show first tile")
+	assert.Equal(t, uint8(255), canvas.NRGBAAt(95, 25).R, "Right edge should show second tile")
+	assert.InDelta(t, 128, int(canvas.NRGBAAt(50, 25).R), 30, "Seam center should blend both tiles")
+}
+
+func TestGridUsesFilter(t *testing.T) {
+	tile := imaging.New(2, 1, color.NRGBA{0, 0, 0, 255})
+	tile.SetNRGBA(1, 0, color.NRGBA{255, 255, 255, 255})
+
+	nearest := Grid(100, 50, []image.Image{tile}, 1, 0, imaging.NearestNeighbor)
+	linear := Grid(100, 50, []image.Image{tile}, 1, 0, imaging.Linear)
+
+	assert.Equal(t, uint8(0), nearest.NRGBAAt(45, 25).R, "Nearest neighbour should keep hard tile edges")
+	assert.Greater(t, linear.NRGBAAt(45, 25).R, uint8(0), "Linear filter should blend tile edges")
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	FitCover       = "cover"
+	FitContain     = "contain"
+	FitContainBlur = "contain_blur"
+	FitStretch     = "stretch"
+
+	backdropDownscale  = 8
+	backdropBlur       = 6.0
+	backdropBrightness = -35.0
+)
+
+var resampleFilters = map[string]imaging.ResampleFilter{
+	"nearest":    imaging.NearestNeighbor,
+	"box":        imaging.Box,
+	"linear":     imaging.Linear,
+	"hermite":    imaging.Hermite,
+	"mitchell":   imaging.MitchellNetravali,
+	"catmullrom": imaging.CatmullRom,
+	"bspline":    imaging.BSpline,
+	"gaussian":   imaging.Gaussian,
+	"bartlett":   imaging.Bartlett,
+	"lanczos":    imaging.Lanczos,
+	"hann":       imaging.Hann,
+	"hamming":    imaging.Hamming,
+	"blackman":   imaging.Blackman,
+	"welch":      imaging.Welch,
+	"cosine":     imaging.Cosine,
+}
+
+// ParseResampleFilter maps a filter name to an imaging resampling filter.
+// An empty name selects Lanczos.
+func ParseResampleFilter(name string) (imaging.ResampleFilter, error) {
+	if name == "" {
+		return imaging.Lanczos, nil
+	}
+
+	filter, ok := resampleFilters[strings.ToLower(name)]
+	if !ok {
+		return imaging.ResampleFilter{}, fmt.Errorf("unknown resample filter: %q", name)
+	}
+	return filter, nil
+}
+
+// Contain scales image to fit inside target dimensions and centers it on a
+// solid background
+func Contain(img image.Image, width, height int, filter imaging.ResampleFilter, background color.Color) *image.NRGBA {
+	canvas := imaging.New(width, height, background)
+	return imaging.PasteCenter(canvas, scaleToFit(img, width, height, filter))
+}
+
+// ContainBlur scales image to fit inside target dimensions and centers it over
+// a blurred, darkened copy of itself that covers the whole canvas
+func ContainBlur(img image.Image, width, height int, filter imaging.ResampleFilter) *image.NRGBA {
+	backdrop := imaging.Fill(img, max(1, width/backdropDownscale), max(1, height/backdropDownscale), imaging.Center, imaging.Box)
+	backdrop = imaging.Blur(backdrop, backdropBlur)
+	backdrop = imaging.AdjustBrightness(backdrop, backdropBrightness)
+	backdrop = imaging.Resize(backdrop, width, height, imaging.Linear)
+
+	return imaging.PasteCenter(backdrop, scaleToFit(img, width, height, filter))
+}
+
+// scaleToFit scales image up or down to the largest size that fits inside
+// target dimensions while keeping its aspect ratio
+func scaleToFit(img image.Image, width, height int, filter imaging.ResampleFilter) *image.NRGBA {
+	srcWidth, srcHeight := img.Bounds().Dx(), img.Bounds().Dy()
+	if srcWidth == 0 || srcHeight == 0 {
+		return imaging.Clone(img)
+	}
+
+	scale := min(float64(width)/float64(srcWidth), float64(height)/float64(srcHeight))
+	fitWidth := max(1, int(math.Round(float64(srcWidth)*scale)))
+	fitHeight := max(1, int(math.Round(float64(srcHeight)*scale)))
+	return imaging.Resize(img, min(fitWidth, width), min(fitHeight, height), filter)
+}
@@ -0,0 +1,58 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResampleFilter(t *testing.T) {
+	filter, err := ParseResampleFilter("")
+	require.NoError(t, err, "Empty filter name should select default")
+	assert.Equal(t, imaging.Lanczos.Support, filter.Support, "Default filter should be Lanczos")
+
+	filter, err = ParseResampleFilter("CatmullRom")
+	require.NoError(t, err, "Filter names should be case insensitive")
+	assert.Equal(t, imaging.CatmullRom.Support, filter.Support, "Filter should match requested name")
+
+	_, err = ParseResampleFilter("unknown")
+	assert.Error(t, err, "Unknown filter should return an error")
+}
+
+func TestContain(t *testing.T) {
+	img := imaging.New(40, 30, color.NRGBA{255, 255, 255, 255})
+	background := color.NRGBA{10, 20, 30, 255}
+
+	result := Contain(img, 320, 120, imaging.Linear, background)
+
+	assert.Equal(t, image.Rect(0, 0, 320, 120), result.Bounds(), "Result should match target dimensions")
+	assert.Equal(t, background, result.NRGBAAt(5, 60), "Letterbox should use background color")
+	assert.Equal(t, color.NRGBA{255, 255, 255, 255}, result.NRGBAAt(160, 60), "Image should be centered")
+	assert.Equal(t, color.NRGBA{255, 255, 255, 255}, result.NRGBAAt(160, 0), "Scaled image should reach the top edge")
+	assert.Equal(t, color.NRGBA{255, 255, 255, 255}, result.NRGBAAt(160, 119), "Scaled image should reach the bottom edge")
+	assert.Equal(t, color.NRGBA{255, 255, 255, 255}, result.NRGBAAt(85, 60), "Small source should be enlarged to 160 pixels wide")
+	assert.Equal(t, background, result.NRGBAAt(75, 60), "Enlarged image should keep its aspect ratio")
+
+	large := Contain(imaging.New(800, 200, color.NRGBA{255, 255, 255, 255}), 320, 120, imaging.Linear, background)
+	assert.Equal(t, color.NRGBA{255, 255, 255, 255}, large.NRGBAAt(0, 60), "Wide source should fill the canvas width")
+	assert.Equal(t, background, large.NRGBAAt(160, 5), "Wide source should be letterboxed")
+}
+
+func TestContainBlur(t *testing.T) {
+	img := imaging.New(40, 30, color.NRGBA{200, 200, 200, 255})
+
+	result := ContainBlur(img, 320, 120, imaging.Linear)
+
+	assert.Equal(t, image.Rect(0, 0, 320, 120), result.Bounds(), "Result should match target dimensions")
+	assert.Less(t, result.NRGBAAt(5, 60).R, uint8(200), "Backdrop should be darkened")
+	assert.Greater(t, result.NRGBAAt(5, 60).R, uint8(0), "Backdrop should show image content")
+	assert.Equal(t, uint8(200), result.NRGBAAt(160, 0).R, "Scaled image should fill the canvas height")
+}
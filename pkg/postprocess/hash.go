@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"image"
+	"math/bits"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	hashWidth  = 9
+	hashHeight = 8
+)
+
+// DifferenceHash computes 64 bit perceptual dHash of an image. Each bit tells
+// whether a pixel of a downscaled 9x8 grayscale copy is brighter than its
+// right neighbour, so small re-encodes keep the hash stable.
+func DifferenceHash(img image.Image) uint64 {
+	small := imaging.Grayscale(imaging.Resize(img, hashWidth, hashHeight, imaging.Box))
+
+	var hash uint64
+	for y := 0; y < hashHeight; y++ {
+		row := small.Pix[y*small.Stride:]
+		for x := 0; x < hashWidth-1; x++ {
+			hash <<= 1
+			if row[x*4] > row[(x+1)*4] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+// HammingDistance returns number of differing bits between two hashes
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func gradientImage(width, height int, reverse bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(x * 255 / width)
+			if reverse {
+				v = 255 - v
+			}
+			img.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+func TestDifferenceHash(t *testing.T) {
+	img := gradientImage(320, 240, false)
+	resized := imaging.Resize(img, 160, 120, imaging.Lanczos)
+	reversed := gradientImage(320, 240, true)
+
+	assert.LessOrEqual(t, HammingDistance(DifferenceHash(img), DifferenceHash(resized)), 2, "Resized image should have a near identical hash")
+	assert.Greater(t, HammingDistance(DifferenceHash(img), DifferenceHash(reversed)), 32, "Reversed gradient should have a different hash")
+}
+
+func TestHammingDistance(t *testing.T) {
+	assert.Equal(t, 0, HammingDistance(0xFF, 0xFF), "Equal hashes should have zero distance")
+	assert.Equal(t, 8, HammingDistance(0xFF, 0x00), "Distance should count differing bits")
+}
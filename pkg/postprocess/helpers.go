@@ -9,20 +9,9 @@ import (
 	"image/color"
 )
 
+// CalculateAverageBrightness returns mean luma of the whole image in 0-1 range
 func CalculateAverageBrightness(img image.Image) float64 {
-	var totalBrightness float64
-	bounds := img.Bounds()
-	pixelCount := bounds.Dx() * bounds.Dy()
-
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			r, g, b, _ := img.At(x, y).RGBA()
-			brightness := 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
-			totalBrightness += brightness / 65535 // Normalize to 0-1 range
-		}
-	}
-
-	return totalBrightness / float64(pixelCount)
+	return MeasureImage(img, MeasureOptions{}).Mean
 }
 
 func CalculateScaledOpacity(avgBrightness, minBrightness, maxBrightness, minOpacity, maxOpacity float64) float64 {
@@ -0,0 +1,249 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"math"
+	"unicode/utf16"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	iccHeaderSize        = 128
+	iccColorantTolerance = 0.005
+	jpegMaxSegmentData   = 65533 - 14
+)
+
+// iccDescriptions names generated profiles
+var iccDescriptions = map[string]string{
+	ColorSpaceSRGB:      "sRGB IEC61966-2.1",
+	ColorSpaceDisplayP3: "Display P3",
+	ColorSpaceAdobeRGB:  "Adobe RGB (1998)",
+}
+
+func s15Fixed16(v float64) uint32 {
+	return uint32(int32(math.Round(v * 65536)))
+}
+
+func fromS15Fixed16(v uint32) float64 {
+	return float64(int32(v)) / 65536
+}
+
+func iccXYZ(values ...float64) []byte {
+	data := append([]byte("XYZ "), 0, 0, 0, 0)
+	for _, v := range values {
+		data = binary.BigEndian.AppendUint32(data, s15Fixed16(v))
+	}
+	return data
+}
+
+func iccText(text string) []byte {
+	encoded := utf16.Encode([]rune(text))
+	data := append([]byte("mluc"), 0, 0, 0, 0)
+	data = binary.BigEndian.AppendUint32(data, 1)
+	data = binary.BigEndian.AppendUint32(data, 12)
+	data = append(data, 'e', 'n', 'U', 'S')
+	data = binary.BigEndian.AppendUint32(data, uint32(len(encoded)*2))
+	data = binary.BigEndian.AppendUint32(data, 28)
+	for _, unit := range encoded {
+		data = binary.BigEndian.AppendUint16(data, unit)
+	}
+	return data
+}
+
+// iccTransfer encodes the sRGB curve, or a pure gamma for Adobe RGB, as a parametric curve
+func iccTransfer(space string) []byte {
+	data := append([]byte("para"), 0, 0, 0, 0)
+	if space == ColorSpaceAdobeRGB {
+		data = append(data, 0, 0, 0, 0)
+		return binary.BigEndian.AppendUint32(data, s15Fixed16(adobeRGBGamma))
+	}
+
+	data = append(data, 0, 3, 0, 0)
+	for _, v := range []float64{2.4, 1 / 1.055, 0.055 / 1.055, 1 / 12.92, 0.04045} {
+		data = binary.BigEndian.AppendUint32(data, s15Fixed16(v))
+	}
+	return data
+}
+
+// ICCProfile builds an ICC v4 display profile for a known color space
+func ICCProfile(space string) ([]byte, error) {
+	chroma, ok := colorSpaces[space]
+	if !ok {
+		return nil, fmt.Errorf("unknown color space: %q", space)
+	}
+
+	colorants := chroma.colorantsD50()
+	adaptation := adaptToD50(chroma.white)
+
+	chad := append([]byte("sf32"), 0, 0, 0, 0)
+	for _, row := range adaptation {
+		for _, v := range row {
+			chad = binary.BigEndian.AppendUint32(chad, s15Fixed16(v))
+		}
+	}
+
+	transfer := iccTransfer(space)
+	tags := []struct {
+		signature string
+		data      []byte
+	}{
+		{"desc", iccText(iccDescriptions[space])},
+		{"cprt", iccText("No copyright, use freely")},
+		{"wtpt", iccXYZ(d50[0], d50[1], d50[2])},
+		{"chad", chad},
+		{"rXYZ", iccXYZ(colorants[0][0], colorants[1][0], colorants[2][0])},
+		{"gXYZ", iccXYZ(colorants[0][1], colorants[1][1], colorants[2][1])},
+		{"bXYZ", iccXYZ(colorants[0][2], colorants[1][2], colorants[2][2])},
+		{"rTRC", transfer},
+		{"gTRC", transfer},
+		{"bTRC", transfer},
+	}
+
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+	var body []byte
+	offset := iccHeaderSize + 4 + len(tags)*12
+	// Identical tag data, such as the three transfer curves, is stored once
+	offsets := make(map[string]int)
+	for _, tag := range tags {
+		tagOffset, shared := offsets[string(tag.data)]
+		if !shared {
+			tagOffset = offset + len(body)
+			offsets[string(tag.data)] = tagOffset
+			body = append(body, tag.data...)
+			for len(body)%4 != 0 {
+				body = append(body, 0)
+			}
+		}
+		table = append(table, tag.signature...)
+		table = binary.BigEndian.AppendUint32(table, uint32(tagOffset))
+		table = binary.BigEndian.AppendUint32(table, uint32(len(tag.data)))
+	}
+
+	header := make([]byte, iccHeaderSize)
+	binary.BigEndian.PutUint32(header[0:], uint32(iccHeaderSize+len(table)+len(body)))
+	binary.BigEndian.PutUint32(header[8:], 0x04300000)
+	copy(header[12:], "mntrRGB XYZ ")
+	for i, v := range []uint16{2025, 1, 1} {
+		binary.BigEndian.PutUint16(header[24+i*2:], v)
+	}
+	copy(header[36:], "acsp")
+	for i, v := range d50 {
+		binary.BigEndian.PutUint32(header[68+i*4:], s15Fixed16(v))
+	}
+
+	profile := append(header, table...)
+	return append(profile, body...), nil
+}
+
+// ICCColorSpace identifies a known color space by the colorants of an ICC
+// profile. It returns an empty string for unknown or unparsable profiles.
+func ICCColorSpace(profile []byte) string {
+	if len(profile) < iccHeaderSize+4 || string(profile[36:40]) != "acsp" || string(profile[16:20]) != "RGB " {
+		return ""
+	}
+
+	count := int(binary.BigEndian.Uint32(profile[iccHeaderSize:]))
+	colorants := make(map[string][3]float64)
+	for i := 0; i < count; i++ {
+		entry := iccHeaderSize + 4 + i*12
+		if entry+12 > len(profile) {
+			return ""
+		}
+		signature := string(profile[entry : entry+4])
+		offset := int(binary.BigEndian.Uint32(profile[entry+4:]))
+		if signature != "rXYZ" && signature != "gXYZ" && signature != "bXYZ" {
+			continue
+		}
+		if offset < 0 || offset+20 > len(profile) || string(profile[offset:offset+4]) != "XYZ " {
+			return ""
+		}
+
+		var xyz [3]float64
+		for c := range xyz {
+			xyz[c] = fromS15Fixed16(binary.BigEndian.Uint32(profile[offset+8+c*4:]))
+		}
+		colorants[signature] = xyz
+	}
+	if len(colorants) != 3 {
+		return ""
+	}
+
+	for _, space := range []string{ColorSpaceSRGB, ColorSpaceDisplayP3, ColorSpaceAdobeRGB} {
+		expected := colorSpaces[space].colorantsD50()
+		matches := true
+		for column, signature := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+			for row := 0; row < 3; row++ {
+				if math.Abs(colorants[signature][row]-expected[row][column]) > iccColorantTolerance {
+					matches = false
+				}
+			}
+		}
+		if matches {
+			return space
+		}
+	}
+	return ""
+}
+
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+// EmbedICCProfile inserts an ICC profile into an encoded PNG or JPEG file
+func EmbedICCProfile(data []byte, format imaging.Format, profile []byte, name string) ([]byte, error) {
+	switch format {
+	case imaging.PNG:
+		// Signature followed by the IHDR chunk, which must stay first
+		const ihdrEnd = 8 + 25
+		if len(data) < ihdrEnd || string(data[12:16]) != "IHDR" {
+			return nil, fmt.Errorf("invalid PNG data")
+		}
+
+		var compressed bytes.Buffer
+		writer := zlib.NewWriter(&compressed)
+		if _, err := writer.Write(profile); err != nil {
+			return nil, fmt.Errorf("failed to compress ICC profile: %w", err)
+		}
+		if err := writer.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress ICC profile: %w", err)
+		}
+
+		chunkData := append([]byte(name), 0, 0)
+		chunkData = append(chunkData, compressed.Bytes()...)
+
+		result := append([]byte(nil), data[:ihdrEnd]...)
+		result = append(result, pngChunk("iCCP", chunkData)...)
+		return append(result, data[ihdrEnd:]...), nil
+	case imaging.JPEG:
+		if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+			return nil, fmt.Errorf("invalid JPEG data")
+		}
+		if len(profile) > jpegMaxSegmentData {
+			return nil, fmt.Errorf("ICC profile too large for a single JPEG segment: %d bytes", len(profile))
+		}
+
+		segment := []byte{0xFF, 0xE2}
+		segment = binary.BigEndian.AppendUint16(segment, uint16(2+14+len(profile)))
+		segment = append(segment, "ICC_PROFILE\x00"...)
+		segment = append(segment, 1, 1)
+		segment = append(segment, profile...)
+
+		result := append([]byte(nil), data[:2]...)
+		result = append(result, segment...)
+		return append(result, data[2:]...), nil
+	default:
+		return nil, fmt.Errorf("ICC profiles are not supported for format %s", format)
+	}
+}
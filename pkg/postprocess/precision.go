@@ -0,0 +1,313 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	DitherNone      = "none"
+	DitherOrdered   = "ordered"
+	DitherBlueNoise = "blue_noise"
+
+	blueNoiseSize  = 64
+	blueNoiseSigma = 1.5
+	blueNoiseSeed  = 1
+
+	// DitherTile is the period of every dither pattern. Parts of an image
+	// dithered separately match the whole when they start on multiples of it.
+	DitherTile = blueNoiseSize
+)
+
+var (
+	bayerMatrix = [8][8]int{
+		{0, 32, 8, 40, 2, 34, 10, 42},
+		{48, 16, 56, 24, 50, 18, 58, 26},
+		{12, 44, 4, 36, 14, 46, 6, 38},
+		{60, 28, 52, 20, 62, 30, 54, 22},
+		{3, 35, 11, 43, 1, 33, 9, 41},
+		{51, 19, 59, 27, 49, 17, 57, 25},
+		{15, 47, 7, 39, 13, 45, 5, 37},
+		{63, 31, 55, 23, 61, 29, 53, 21},
+	}
+
+	blueNoiseOnce sync.Once
+	blueNoiseMap  []float64
+)
+
+// ValidateDither checks the dither method name
+func ValidateDither(method string) error {
+	switch method {
+	case "", DitherNone, DitherOrdered, DitherBlueNoise:
+		return nil
+	default:
+		return fmt.Errorf("unknown dither method: %q", method)
+	}
+}
+
+// ToNRGBA64 converts any image to 16-bit NRGBA with bounds starting at zero
+func ToNRGBA64(img image.Image) *image.NRGBA64 {
+	if src, ok := img.(*image.NRGBA64); ok && src.Bounds().Min == (image.Point{}) {
+		return src
+	}
+
+	return copy64(img)
+}
+
+// copy64 converts img into a new 16-bit image with bounds starting at zero
+func copy64(img image.Image) *image.NRGBA64 {
+	bounds := img.Bounds()
+	dst := image.NewNRGBA64(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+// Crop64 copies rect of a 16-bit image into a new image
+func Crop64(img *image.NRGBA64, rect image.Rectangle) *image.NRGBA64 {
+	return copy64(img.SubImage(rect.Intersect(img.Bounds())))
+}
+
+// floatPlanes holds RGBA channels as float32 in [0, 65535]
+type floatPlanes struct {
+	width, height int
+	pix           []float32
+}
+
+func toPlanes(img *image.NRGBA64) floatPlanes {
+	planes := floatPlanes{width: img.Bounds().Dx(), height: img.Bounds().Dy()}
+	planes.pix = make([]float32, planes.width*planes.height*4)
+	for y := 0; y < planes.height; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+planes.width*8]
+		for x := 0; x < planes.width*4; x++ {
+			planes.pix[y*planes.width*4+x] = float32(uint16(row[x*2])<<8 | uint16(row[x*2+1]))
+		}
+	}
+	return planes
+}
+
+func (p floatPlanes) toNRGBA64() *image.NRGBA64 {
+	dst := image.NewNRGBA64(image.Rect(0, 0, p.width, p.height))
+	for i, value := range p.pix {
+		v := uint16(math.Min(math.Max(float64(value)+0.5, 0), 65535))
+		dst.Pix[i*2] = uint8(v >> 8)
+		dst.Pix[i*2+1] = uint8(v)
+	}
+	return dst
+}
+
+// weights holds filter taps for one output position
+type weights struct {
+	start  int
+	values []float32
+}
+
+func filterWeights(srcSize, dstSize int, filter imaging.ResampleFilter) []weights {
+	scale := float64(srcSize) / float64(dstSize)
+	support := filter.Support
+	if scale > 1 {
+		support *= scale
+	}
+
+	result := make([]weights, dstSize)
+	for i := range result {
+		center := (float64(i)+0.5)*scale - 0.5
+
+		if filter.Support <= 0 || filter.Kernel == nil {
+			index := min(max(int(math.Round(center)), 0), srcSize-1)
+			result[i] = weights{start: index, values: []float32{1}}
+			continue
+		}
+
+		start := int(math.Ceil(center - support))
+		end := int(math.Floor(center + support))
+		start = max(start, 0)
+		end = min(end, srcSize-1)
+
+		var sum float64
+		values := make([]float64, 0, end-start+1)
+		for j := start; j <= end; j++ {
+			x := float64(j) - center
+			if scale > 1 {
+				x /= scale
+			}
+			w := filter.Kernel(x)
+			values = append(values, w)
+			sum += w
+		}
+
+		taps := make([]float32, len(values))
+		for j, w := range values {
+			if sum != 0 {
+				taps[j] = float32(w / sum)
+			}
+		}
+		result[i] = weights{start: start, values: taps}
+	}
+	return result
+}
+
+// resampleHorizontal applies taps along rows, transposing the output so a
+// second pass handles the other axis
+func resampleHorizontal(src floatPlanes, taps []weights) floatPlanes {
+	dst := floatPlanes{width: src.height, height: len(taps)}
+	dst.pix = make([]float32, dst.width*dst.height*4)
+
+	for y := 0; y < src.height; y++ {
+		row := src.pix[y*src.width*4 : (y+1)*src.width*4]
+		for x, tap := range taps {
+			var r, g, b, a float32
+			for k, w := range tap.values {
+				i := (tap.start + k) * 4
+				r += row[i] * w
+				g += row[i+1] * w
+				b += row[i+2] * w
+				a += row[i+3] * w
+			}
+			o := (x*dst.width + y) * 4
+			dst.pix[o], dst.pix[o+1], dst.pix[o+2], dst.pix[o+3] = r, g, b, a
+		}
+	}
+	return dst
+}
+
+// Resize64 scales img with the given imaging filter without reducing
+// precision to 8 bits
+func Resize64(img image.Image, width, height int, filter imaging.ResampleFilter) *image.NRGBA64 {
+	src := ToNRGBA64(img)
+	if width <= 0 || height <= 0 {
+		return image.NewNRGBA64(image.Rect(0, 0, max(width, 0), max(height, 0)))
+	}
+
+	planes := toPlanes(src)
+	planes = resampleHorizontal(planes, filterWeights(planes.width, width, filter))
+	planes = resampleHorizontal(planes, filterWeights(planes.width, height, filter))
+	return planes.toNRGBA64()
+}
+
+// Blur64 applies a gaussian blur with standard deviation sigma in 16-bit precision
+func Blur64(img image.Image, sigma float64) *image.NRGBA64 {
+	src := ToNRGBA64(img)
+	if sigma <= 0 {
+		return src
+	}
+
+	radius := int(math.Ceil(sigma * 3))
+	kernel := make([]float64, 2*radius+1)
+	for i := range kernel {
+		x := float64(i - radius)
+		kernel[i] = math.Exp(-x * x / (2 * sigma * sigma))
+	}
+
+	blurTaps := func(size int) []weights {
+		taps := make([]weights, size)
+		for i := range taps {
+			start := max(i-radius, 0)
+			end := min(i+radius, size-1)
+
+			var sum float64
+			for j := start; j <= end; j++ {
+				sum += kernel[j-i+radius]
+			}
+			values := make([]float32, 0, end-start+1)
+			for j := start; j <= end; j++ {
+				values = append(values, float32(kernel[j-i+radius]/sum))
+			}
+			taps[i] = weights{start: start, values: values}
+		}
+		return taps
+	}
+
+	planes := toPlanes(src)
+	planes = resampleHorizontal(planes, blurTaps(planes.width))
+	planes = resampleHorizontal(planes, blurTaps(planes.width))
+	return planes.toNRGBA64()
+}
+
+// blueNoiseThresholds builds a tileable blue noise threshold map by high-pass
+// filtering white noise and ranking the result into a uniform distribution
+func blueNoiseThresholds() []float64 {
+	blueNoiseOnce.Do(func() {
+		random := rand.New(rand.NewSource(blueNoiseSeed))
+		noise := make([]float64, blueNoiseSize*blueNoiseSize)
+		for i := range noise {
+			noise[i] = random.Float64()
+		}
+
+		radius := int(math.Ceil(blueNoiseSigma * 3))
+		highPass := make([]float64, len(noise))
+		for y := 0; y < blueNoiseSize; y++ {
+			for x := 0; x < blueNoiseSize; x++ {
+				var blurred, sum float64
+				for dy := -radius; dy <= radius; dy++ {
+					for dx := -radius; dx <= radius; dx++ {
+						w := math.Exp(-float64(dx*dx+dy*dy) / (2 * blueNoiseSigma * blueNoiseSigma))
+						sx := (x + dx + blueNoiseSize) % blueNoiseSize
+						sy := (y + dy + blueNoiseSize) % blueNoiseSize
+						blurred += noise[sy*blueNoiseSize+sx] * w
+						sum += w
+					}
+				}
+				highPass[y*blueNoiseSize+x] = noise[y*blueNoiseSize+x] - blurred/sum
+			}
+		}
+
+		order := make([]int, len(highPass))
+		for i := range order {
+			order[i] = i
+		}
+		sort.Slice(order, func(a, b int) bool { return highPass[order[a]] < highPass[order[b]] })
+
+		blueNoiseMap = make([]float64, len(order))
+		for rank, index := range order {
+			blueNoiseMap[index] = (float64(rank) + 0.5) / float64(len(order))
+		}
+	})
+	return blueNoiseMap
+}
+
+// Dither quantizes a high precision image to 8 bits per channel. Thresholds
+// vary per pixel, so smooth gradients turn into fine grain instead of bands.
+func Dither(img image.Image, method string) *image.NRGBA {
+	src := ToNRGBA64(img)
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	threshold := func(x, y int) float64 { return 0.5 }
+	switch method {
+	case DitherOrdered:
+		threshold = func(x, y int) float64 {
+			return (float64(bayerMatrix[y%8][x%8]) + 0.5) / 64
+		}
+	case DitherBlueNoise:
+		thresholds := blueNoiseThresholds()
+		threshold = func(x, y int) float64 {
+			return thresholds[(y%blueNoiseSize)*blueNoiseSize+x%blueNoiseSize]
+		}
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			t := threshold(x, y)
+			i := y*src.Stride + x*8
+			o := y*dst.Stride + x*4
+			for c := 0; c < 3; c++ {
+				value := float64(uint16(src.Pix[i+c*2])<<8|uint16(src.Pix[i+c*2+1])) / 257
+				dst.Pix[o+c] = uint8(math.Min(math.Floor(value+t), 255))
+			}
+			dst.Pix[o+3] = src.Pix[i+6]
+		}
+	}
+
+	return dst
+}
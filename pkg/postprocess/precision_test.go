@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// shallowGradient returns a 16-bit gradient spanning only a few 8-bit levels
+func shallowGradient(width, height int) *image.NRGBA64 {
+	img := image.NewNRGBA64(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint16(100*257 + x*4*257/width)
+			img.SetNRGBA64(x, y, color.NRGBA64{v, v, v, 0xffff})
+		}
+	}
+	return img
+}
+
+func TestDither(t *testing.T) {
+	img := shallowGradient(256, 8)
+
+	for _, method := range []string{DitherOrdered, DitherBlueNoise} {
+		dithered := Dither(img, method)
+		require.Equal(t, img.Bounds(), dithered.Bounds(), "Dither should keep dimensions")
+
+		// Average of a dithered column should track the precise value
+		for _, x := range []int{32, 96, 160, 224} {
+			sum := 0.0
+			for y := 0; y < 8; y++ {
+				sum += float64(dithered.NRGBAAt(x, y).R)
+			}
+			precise := float64(img.NRGBA64At(x, 0).R) / 257
+			assert.InDelta(t, precise, sum/8, 0.6, "Dithered mean should match precise value with %s", method)
+		}
+	}
+
+	plain := Dither(img, DitherNone)
+	assert.Equal(t, uint8(255), plain.NRGBAAt(0, 0).A, "Alpha should be kept")
+	assert.NoError(t, ValidateDither(DitherBlueNoise), "Blue noise should be valid")
+	assert.Error(t, ValidateDither("floyd"), "Unknown method should fail")
+}
+
+func TestBlueNoiseThresholds(t *testing.T) {
+	thresholds := blueNoiseThresholds()
+	require.Len(t, thresholds, blueNoiseSize*blueNoiseSize, "Threshold map should cover tile")
+
+	sum := 0.0
+	for _, v := range thresholds {
+		assert.True(t, v > 0 && v < 1, "Thresholds should be in (0, 1)")
+		sum += v
+	}
+	assert.InDelta(t, 0.5, sum/float64(len(thresholds)), 0.001, "Thresholds should be uniformly distributed")
+}
+
+func TestResize64(t *testing.T) {
+	src := imaging.New(40, 20, color.NRGBA{200, 100, 50, 255})
+
+	resized := Resize64(src, 80, 30, imaging.Lanczos)
+	assert.Equal(t, image.Rect(0, 0, 80, 30), resized.Bounds(), "Resize64 should produce target size")
+	c := resized.NRGBA64At(40, 15)
+	assert.InDelta(t, 200*257, int(c.R), 257, "Flat color should survive resampling")
+
+	nearest := Resize64(src, 10, 5, imaging.NearestNeighbor)
+	assert.Equal(t, image.Rect(0, 0, 10, 5), nearest.Bounds(), "Nearest neighbor should be supported")
+}
+
+func TestBlur64(t *testing.T) {
+	img := image.NewNRGBA64(image.Rect(0, 0, 32, 1))
+	for x := 0; x < 32; x++ {
+		v := uint16(0)
+		if x >= 16 {
+			v = 0xffff
+		}
+		img.SetNRGBA64(x, 0, color.NRGBA64{v, v, v, 0xffff})
+	}
+
+	blurred := Blur64(img, 2)
+	assert.Greater(t, blurred.NRGBA64At(15, 0).R, uint16(0), "Blur should spread edge")
+	assert.Less(t, blurred.NRGBA64At(16, 0).R, uint16(0xffff), "Blur should soften edge")
+	assert.Equal(t, uint16(0xffff), blurred.NRGBA64At(16, 0).A, "Opaque alpha should stay opaque")
+}
+
+func TestCrop64(t *testing.T) {
+	img := shallowGradient(20, 10)
+	cropped := Crop64(img, image.Rect(5, 2, 15, 8))
+	assert.Equal(t, image.Rect(0, 0, 10, 6), cropped.Bounds(), "Crop64 should rebase bounds to zero")
+	assert.Equal(t, img.NRGBA64At(5, 2), cropped.NRGBA64At(0, 0), "Crop64 should copy pixels")
+}
@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	sharpnessSampleWidth = 512
+	uniformTolerance     = 2
+)
+
+// QualityGate decides whether a downloaded frame is good enough to become a
+// wallpaper. Thresholds left at zero are not checked.
+type QualityGate struct {
+	MinBrightness      float64 `yaml:"min_brightness"`
+	MaxBrightness      float64 `yaml:"max_brightness"`
+	MinSharpness       float64 `yaml:"min_sharpness"`
+	MaxUniformFraction float64 `yaml:"max_uniform_fraction"`
+}
+
+// Evaluate returns an error describing why a frame was rejected
+func (g QualityGate) Evaluate(img image.Image) error {
+	if g.MaxUniformFraction > 0 {
+		if fraction := UniformBottomFraction(img); fraction > g.MaxUniformFraction {
+			return fmt.Errorf("frame looks truncated: uniform bottom region %.2f exceeds %.2f", fraction, g.MaxUniformFraction)
+		}
+	}
+
+	if g.MinBrightness > 0 || g.MaxBrightness > 0 {
+		brightness := CalculateAverageBrightness(img)
+		if g.MinBrightness > 0 && brightness < g.MinBrightness {
+			return fmt.Errorf("frame too dark: brightness %.3f below %.3f", brightness, g.MinBrightness)
+		}
+		if g.MaxBrightness > 0 && brightness > g.MaxBrightness {
+			return fmt.Errorf("frame too bright: brightness %.3f above %.3f", brightness, g.MaxBrightness)
+		}
+	}
+
+	if g.MinSharpness > 0 {
+		if sharpness := LaplacianVariance(img); sharpness < g.MinSharpness {
+			return fmt.Errorf("frame too blurry: sharpness %.1f below %.1f", sharpness, g.MinSharpness)
+		}
+	}
+
+	return nil
+}
+
+// LaplacianVariance measures sharpness as variance of 4-neighbour Laplacian
+// of grayscale image. Large images are downscaled first so result does not
+// depend on source resolution.
+func LaplacianVariance(img image.Image) float64 {
+	if img.Bounds().Dx() > sharpnessSampleWidth {
+		img = imaging.Resize(img, sharpnessSampleWidth, 0, imaging.Box)
+	}
+	gray := imaging.Grayscale(img)
+
+	width := gray.Bounds().Dx()
+	height := gray.Bounds().Dy()
+	if width < 3 || height < 3 {
+		return 0
+	}
+
+	at := func(x, y int) float64 {
+		return float64(gray.Pix[y*gray.Stride+x*4])
+	}
+
+	var sum, sumSquares float64
+	count := float64((width - 2) * (height - 2))
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			laplacian := at(x-1, y) + at(x+1, y) + at(x, y-1) + at(x, y+1) - 4*at(x, y)
+			sum += laplacian
+			sumSquares += laplacian * laplacian
+		}
+	}
+
+	mean := sum / count
+	return sumSquares/count - mean*mean
+}
+
+// UniformBottomFraction returns fraction of image height, counted from the
+// bottom, that is filled with a single flat color. Truncated JPEGs decode
+// with such a gray block below real content, so the block only counts when
+// the region above it is not uniform. Entirely flat frames, such as black
+// night frames, are left to the brightness check.
+func UniformBottomFraction(img image.Image) float64 {
+	src := imaging.Clone(img)
+	width := src.Bounds().Dx()
+	height := src.Bounds().Dy()
+	if width == 0 || height == 0 {
+		return 0
+	}
+
+	reference := src.Pix[(height-1)*src.Stride : (height-1)*src.Stride+4]
+
+	uniformRows := 0
+	for y := height - 1; y >= 0; y-- {
+		row := src.Pix[y*src.Stride : y*src.Stride+width*4]
+		if !rowMatches(row, reference) {
+			break
+		}
+		uniformRows++
+	}
+
+	content := height - uniformRows
+	if uniformRows == 0 || content == 0 || regionUniform(src, content) {
+		return 0
+	}
+	return float64(uniformRows) / float64(height)
+}
+
+// regionUniform reports whether the first rows of img share a single flat color
+func regionUniform(img *image.NRGBA, rows int) bool {
+	width := img.Bounds().Dx()
+	reference := img.Pix[0:4]
+	for y := 0; y < rows; y++ {
+		if !rowMatches(img.Pix[y*img.Stride:y*img.Stride+width*4], reference) {
+			return false
+		}
+	}
+	return true
+}
+
+func rowMatches(row, reference []uint8) bool {
+	for i := 0; i < len(row); i += 4 {
+		for c := 0; c < 3; c++ {
+			diff := int(row[i+c]) - int(reference[c])
+			if diff > uniformTolerance || diff < -uniformTolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
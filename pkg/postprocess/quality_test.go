@@ -0,0 +1,61 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func checkerImage(width, height, cell int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(0)
+			if (x/cell+y/cell)%2 == 0 {
+				v = 255
+			}
+			img.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+func TestLaplacianVariance(t *testing.T) {
+	sharp := checkerImage(64, 64, 4)
+	blurred := imaging.Blur(sharp, 4)
+
+	assert.Greater(t, LaplacianVariance(sharp), LaplacianVariance(blurred), "Sharp image should have a higher Laplacian variance")
+}
+
+func TestUniformBottomFraction(t *testing.T) {
+	img := checkerImage(40, 100, 4)
+	draw.Draw(img, image.Rect(0, 60, 40, 100), &image.Uniform{color.NRGBA{128, 128, 128, 255}}, image.Point{}, draw.Src)
+
+	assert.InDelta(t, 0.4, UniformBottomFraction(img), 0.01, "Uniform bottom region should be detected")
+	assert.InDelta(t, 0.0, UniformBottomFraction(checkerImage(40, 100, 4)), 0.01, "Detailed image should have no uniform bottom")
+
+	night := imaging.New(40, 100, color.NRGBA{0, 0, 0, 255})
+	assert.Equal(t, 0.0, UniformBottomFraction(night), "All black night frame should not count as truncated")
+
+	split := imaging.New(40, 100, color.NRGBA{0, 0, 0, 255})
+	draw.Draw(split, image.Rect(0, 60, 40, 100), &image.Uniform{color.NRGBA{128, 128, 128, 255}}, image.Point{}, draw.Src)
+	assert.Equal(t, 0.0, UniformBottomFraction(split), "Flat block below a flat region should not count as truncated")
+}
+
+func TestQualityGateEvaluate(t *testing.T) {
+	dark := imaging.New(64, 64, color.NRGBA{5, 5, 5, 255})
+
+	assert.NoError(t, QualityGate{}.Evaluate(dark), "Empty gate should accept every frame")
+	assert.NoError(t, QualityGate{MaxUniformFraction: 0.3}.Evaluate(dark), "Flat dark frame should not be rejected as truncated")
+	assert.Error(t, QualityGate{MinBrightness: 0.1}.Evaluate(dark), "Dark frame should be rejected")
+	assert.Error(t, QualityGate{MinSharpness: 10}.Evaluate(dark), "Flat frame should be rejected as blurry")
+	assert.NoError(t, QualityGate{MinSharpness: 10}.Evaluate(checkerImage(64, 64, 4)), "Detailed frame should pass sharpness check")
+}
@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"time"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/disintegration/imaging"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// clockRegionPadding covers antialiasing outside glyph bounds
+	clockRegionPadding = 2
+
+	// maxCachedFaces bounds parsed faces kept between draws, the clock and
+	// badges each use one
+	maxCachedFaces = 8
+)
+
+type faceKey struct {
+	fontPath string
+	style    FontStyle
+	size     float64
+	dpi      float64
+}
+
+// ClockRenderer draws the time and badges and keeps parsed font faces
+// between draws. The zero value is ready to use.
+type ClockRenderer struct {
+	faces map[faceKey]font.Face
+}
+
+// ClockLayout places the time text on a frame
+type ClockLayout struct {
+	Text string
+	// Region covers every pixel the text touches
+	Region image.Rectangle
+
+	dot        fixed.Point26_6
+	face       font.Face
+	fontConfig FontConfig
+}
+
+func (r *ClockRenderer) loadFace(fontConfig FontConfig) (font.Face, error) {
+	key := faceKey{fontPath: fontConfig.FontPath, style: fontConfig.Style, size: fontConfig.Size, dpi: fontConfig.DPI}
+	if face, ok := r.faces[key]; ok {
+		return face, nil
+	}
+
+	fontBytes, err := getFontBytes(fontConfig.FontPath, fontConfig.Style)
+	if err != nil {
+		return nil, err
+	}
+	fnt, err := truetype.Parse(fontBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+
+	if r.faces == nil || len(r.faces) >= maxCachedFaces {
+		r.faces = make(map[faceKey]font.Face)
+	}
+	face := truetype.NewFace(fnt, &truetype.Options{
+		Size: fontConfig.Size,
+		DPI:  fontConfig.DPI,
+	})
+	r.faces[key] = face
+	return face, nil
+}
+
+// Layout formats now and positions it within bounds
+func (r *ClockRenderer) Layout(bounds image.Rectangle, fontConfig FontConfig, now time.Time) (ClockLayout, error) {
+	face, err := r.loadFace(fontConfig)
+	if err != nil {
+		return ClockLayout{}, err
+	}
+
+	text := now.Format(fontConfig.TimeFormat)
+	textWidth := font.MeasureString(face, text).Ceil()
+	textHeight := face.Metrics().Height.Ceil()
+	imgWidth := bounds.Dx()
+	imgHeight := bounds.Dy()
+
+	var centerX, centerY int
+
+	// Horizontal Alignment with Offset
+	switch fontConfig.Position.HorizontalAlignment {
+	case AlignLeft:
+		centerX = fontConfig.Position.PaddingLeft
+	case AlignRight:
+		centerX = imgWidth - textWidth - fontConfig.Position.PaddingRight
+	default: // center
+		centerX = (imgWidth-textWidth)/2 + fontConfig.Position.HorizontalCenterOffset
+	}
+
+	// Vertical Alignment with Offset
+	switch fontConfig.Position.VerticalAlignment {
+	case AlignTop:
+		centerY = fontConfig.Position.PaddingTop + textHeight
+	case AlignBottom:
+		centerY = imgHeight - fontConfig.Position.PaddingBottom
+	default: // center
+		centerY = (imgHeight+textHeight)/2 + fontConfig.Position.VerticalCenterOffset
+	}
+
+	dot := fixed.P(bounds.Min.X+centerX, bounds.Min.Y+centerY)
+	glyphs, _ := font.BoundString(face, text)
+	region := image.Rect(
+		(dot.X + glyphs.Min.X).Floor(), (dot.Y + glyphs.Min.Y).Floor(),
+		(dot.X + glyphs.Max.X).Ceil(), (dot.Y + glyphs.Max.Y).Ceil(),
+	).Inset(-clockRegionPadding).Intersect(bounds)
+
+	return ClockLayout{Text: text, Region: region, dot: dot, face: face, fontConfig: fontConfig}, nil
+}
+
+// DrawRegion draws layout onto region of img and returns only that region,
+// with bounds starting at zero. Region must contain layout.Region. Stats of
+// img scale text opacity.
+func (r *ClockRenderer) DrawRegion(img image.Image, layout ClockLayout, region image.Rectangle, stats *postprocess.ImageStats) *image.NRGBA {
+	region = region.Intersect(img.Bounds())
+	under := imaging.Crop(img, region)
+
+	textImage := image.NewRGBA(under.Bounds())
+	draw.Draw(textImage, textImage.Bounds(), under, image.Point{}, draw.Src)
+
+	d := &font.Drawer{
+		Dst:  textImage,
+		Src:  image.NewUniform(layout.fontConfig.Color),
+		Face: layout.face,
+		Dot:  layout.dot.Sub(fixed.P(region.Min.X, region.Min.Y)),
+	}
+	d.DrawString(layout.Text)
+
+	scaledOpacity := postprocess.CalculateScaledOpacity(stats.Mean, 0.0, 0.4, layout.fontConfig.MinOpacity, layout.fontConfig.MaxOpacity)
+	return imaging.Overlay(under, textImage, image.Point{}, scaledOpacity)
+}
+
+// Draw returns a copy of img with the current time drawn on it. Only the
+// text region is rendered, the rest is copied. Nil stats measure img.
+func (r *ClockRenderer) Draw(img image.Image, fontConfig FontConfig, stats *postprocess.ImageStats) (*image.NRGBA, error) {
+	layout, err := r.Layout(img.Bounds(), fontConfig, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		stats = postprocess.MeasureImage(img, postprocess.MeasureOptions{})
+	}
+
+	dst := imaging.Clone(img)
+	patch := r.DrawRegion(img, layout, layout.Region, stats)
+	draw.Draw(dst, layout.Region.Sub(img.Bounds().Min), patch, image.Point{}, draw.Src)
+	return dst, nil
+}
@@ -0,0 +1,39 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package render
+
+import (
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrawBadgeReusesFaces(t *testing.T) {
+	img := imaging.New(200, 100, color.NRGBA{0, 0, 0, 255})
+	clockConfig := FontConfig{Size: 12, DPI: 72, Color: color.White, MaxOpacity: 1, TimeFormat: "15:04"}
+
+	var renderer ClockRenderer
+	_, err := renderer.Draw(img, clockConfig, nil)
+	require.NoError(t, err, "Drawing clock should succeed")
+
+	badged, err := renderer.DrawBadge(img, "stale", 8, 1)
+	require.NoError(t, err, "Drawing badge should succeed")
+	assert.NotEqual(t, imaging.Clone(img).Pix, imaging.Clone(badged).Pix, "Badge should be drawn")
+	assert.Len(t, renderer.faces, 2, "Clock and badge faces should both be cached")
+
+	_, err = renderer.DrawBadge(img, "stale", 8, 1)
+	require.NoError(t, err, "Drawing badge again should succeed")
+	assert.Len(t, renderer.faces, 2, "Cached badge face should be reused")
+}
+
+func TestLoadFaceMissingFont(t *testing.T) {
+	var renderer ClockRenderer
+	_, err := renderer.loadFace(FontConfig{FontPath: filepath.Join(t.TempDir(), "missing.ttf"), Size: 12, DPI: 72})
+	assert.Error(t, err, "Missing font file should be reported")
+}
@@ -6,21 +6,20 @@ package render
 
 import (
 	_ "embed"
+	"fmt"
 	"image"
 	"image/color"
-	"image/draw"
-	"log"
 	"os"
 	"path/filepath"
-	"time"
 
-	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
 	"github.com/disintegration/imaging"
-	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/font"
 	"golang.org/x/image/math/fixed"
 )
 
+// badgeDPI renders badges at the resolution of high density displays
+const badgeDPI = 144
+
 // Embed default font files
 //
 //go:embed assets/fonts/Lora/Lora-Regular.ttf
@@ -77,13 +76,13 @@ type FontPositionConfig struct {
 	HorizontalCenterOffset int
 }
 
-func getFontBytes(customPath string, style ...FontStyle) []byte {
+func getFontBytes(customPath string, style ...FontStyle) ([]byte, error) {
 	if customPath != "" {
 		fontBytes, err := os.ReadFile(filepath.Clean(customPath))
 		if err != nil {
-			log.Fatalf("failed to read font file: %v", err)
+			return nil, fmt.Errorf("failed to read font file: %w", err)
 		}
-		return fontBytes
+		return fontBytes, nil
 	}
 
 	var selectedStyle FontStyle
@@ -95,80 +94,31 @@ func getFontBytes(customPath string, style ...FontStyle) []byte {
 
 	switch selectedStyle {
 	case Bold:
-		return defaultFontBold
+		return defaultFontBold, nil
 	case Italic:
-		return defaultFontItalic
+		return defaultFontItalic, nil
 	default:
-		return defaultFontRegular
+		return defaultFontRegular, nil
 	}
 }
 
-func DrawTimeOnImage(img image.Image, fontConfig FontConfig) image.Image {
-	bounds := img.Bounds()
-	rgba := image.NewRGBA(bounds)
-	draw.Draw(rgba, bounds, img, image.Point{}, draw.Src)
-
-	// Get font bytes from embedded font data
-	fontBytes := getFontBytes(fontConfig.FontPath, fontConfig.Style)
-
-	// Parse font
-	fnt, err := truetype.Parse(fontBytes)
+// DrawBadge returns a copy of img with text drawn in the top left corner,
+// using the italic default font
+func (r *ClockRenderer) DrawBadge(img image.Image, text string, size float64, opacity float64) (image.Image, error) {
+	face, err := r.loadFace(FontConfig{Style: Italic, Size: size, DPI: badgeDPI})
 	if err != nil {
-		log.Fatalf("failed to parse font: %v", err)
+		return nil, err
 	}
 
-	face := truetype.NewFace(fnt, &truetype.Options{
-		Size: fontConfig.Size,
-		DPI:  fontConfig.DPI,
-	})
-
-	// Use configurable time format
-	currentTime := time.Now().Format(fontConfig.TimeFormat)
-
+	textImage := image.NewRGBA(img.Bounds())
+	padding := face.Metrics().Height.Ceil()
 	d := &font.Drawer{
-		Dst:  rgba,
-		Src:  image.NewUniform(fontConfig.Color),
+		Dst:  textImage,
+		Src:  image.NewUniform(color.White),
 		Face: face,
+		Dot:  fixed.P(padding, padding*2),
 	}
+	d.DrawString(text)
 
-	textWidth := d.MeasureString(currentTime).Ceil()
-	textHeight := face.Metrics().Height.Ceil()
-	imgWidth := bounds.Dx()
-	imgHeight := bounds.Dy()
-
-	var centerX, centerY int
-
-	// Horizontal Alignment with Offset
-	switch fontConfig.Position.HorizontalAlignment {
-	case AlignLeft:
-		centerX = fontConfig.Position.PaddingLeft
-	case AlignRight:
-		centerX = imgWidth - textWidth - fontConfig.Position.PaddingRight
-	default: // center
-		centerX = (imgWidth-textWidth)/2 + fontConfig.Position.HorizontalCenterOffset
-	}
-
-	// Vertical Alignment with Offset
-	switch fontConfig.Position.VerticalAlignment {
-	case AlignTop:
-		centerY = fontConfig.Position.PaddingTop + textHeight
-	case AlignBottom:
-		centerY = imgHeight - fontConfig.Position.PaddingBottom
-	default: // center
-		centerY = (imgHeight+textHeight)/2 + fontConfig.Position.VerticalCenterOffset
-	}
-
-	point := fixed.P(centerX, centerY)
-
-	d.Dot = point
-	d.DrawString(currentTime)
-
-	textImage := image.NewRGBA(bounds)
-	draw.Draw(textImage, bounds, rgba, image.Point{}, draw.Over)
-
-	avgBrightness := postprocess.CalculateAverageBrightness(img)
-	scaledOpacity := postprocess.CalculateScaledOpacity(avgBrightness, 0.0, 0.4, fontConfig.MinOpacity, fontConfig.MaxOpacity)
-	blended := imaging.Overlay(img, textImage, image.Point{}, scaledOpacity)
-
-	return blended
+	return imaging.Overlay(img, textImage, image.Point{}, opacity), nil
 }
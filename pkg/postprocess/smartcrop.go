@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	CropModeCenter = "center"
+	CropModeSmart  = "smart"
+
+	smartCropSampleWidth = 160
+	smartCropSteps       = 16
+	smartCropBins        = 32
+	anchorBias           = 0.5
+)
+
+// Anchor is a point in normalized image coordinates (0-1) that smart cropping is biased toward
+type Anchor struct {
+	X float64 `yaml:"x"`
+	Y float64 `yaml:"y"`
+}
+
+// SmartCrop returns crop window of given size that covers the most
+// interesting region of an image. Candidate windows are scored by edge
+// density, luminance entropy and saliency. An optional anchor pulls the
+// window toward a point of interest.
+func SmartCrop(img image.Image, width, height int, anchor *Anchor) image.Rectangle {
+	bounds := img.Bounds()
+	srcWidth := bounds.Dx()
+	srcHeight := bounds.Dy()
+	if width >= srcWidth && height >= srcHeight {
+		return bounds
+	}
+	width = min(width, srcWidth)
+	height = min(height, srcHeight)
+
+	scale := 1.0
+	sample := img
+	if srcWidth > smartCropSampleWidth {
+		scale = float64(smartCropSampleWidth) / float64(srcWidth)
+		sample = imaging.Resize(img, smartCropSampleWidth, 0, imaging.Box)
+	}
+
+	m := newScoreMaps(imaging.Grayscale(sample))
+	windowWidth := max(1, min(m.width, int(math.Round(float64(width)*scale))))
+	windowHeight := max(1, min(m.height, int(math.Round(float64(height)*scale))))
+
+	type candidate struct {
+		x, y                     int
+		edges, entropy, saliency float64
+	}
+
+	var candidates []candidate
+	var maxEdges, maxEntropy, maxSaliency float64
+	for _, y := range candidatePositions(m.height - windowHeight) {
+		for _, x := range candidatePositions(m.width - windowWidth) {
+			c := candidate{
+				x:        x,
+				y:        y,
+				edges:    m.edges.mean(x, y, windowWidth, windowHeight),
+				entropy:  m.entropy(x, y, windowWidth, windowHeight),
+				saliency: m.saliency.mean(x, y, windowWidth, windowHeight),
+			}
+			maxEdges = math.Max(maxEdges, c.edges)
+			maxEntropy = math.Max(maxEntropy, c.entropy)
+			maxSaliency = math.Max(maxSaliency, c.saliency)
+			candidates = append(candidates, c)
+		}
+	}
+
+	best := candidates[0]
+	bestScore := math.Inf(-1)
+	for _, c := range candidates {
+		score := (normalize(c.edges, maxEdges) + normalize(c.entropy, maxEntropy) + normalize(c.saliency, maxSaliency)) / 3
+		if anchor != nil {
+			centerX := (float64(c.x) + float64(windowWidth)/2) / float64(m.width)
+			centerY := (float64(c.y) + float64(windowHeight)/2) / float64(m.height)
+			score -= anchorBias * math.Hypot(centerX-anchor.X, centerY-anchor.Y)
+		}
+		if score > bestScore {
+			best = c
+			bestScore = score
+		}
+	}
+
+	x := min(int(math.Round(float64(best.x)/scale)), srcWidth-width)
+	y := min(int(math.Round(float64(best.y)/scale)), srcHeight-height)
+	return image.Rect(x, y, x+width, y+height).Add(bounds.Min)
+}
+
+func candidatePositions(maxOffset int) []int {
+	if maxOffset <= 0 {
+		return []int{0}
+	}
+
+	steps := min(smartCropSteps, maxOffset)
+	positions := make([]int, 0, steps+1)
+	for i := 0; i <= steps; i++ {
+		positions = append(positions, maxOffset*i/steps)
+	}
+	return positions
+}
+
+func normalize(value, maximum float64) float64 {
+	if maximum == 0 {
+		return 0
+	}
+	return value / maximum
+}
+
+type scoreMaps struct {
+	width, height int
+	luma          []uint8
+	edges         summedArea
+	saliency      summedArea
+}
+
+func newScoreMaps(gray *image.NRGBA) scoreMaps {
+	width := gray.Bounds().Dx()
+	height := gray.Bounds().Dy()
+
+	luma := make([]uint8, width*height)
+	var total float64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := gray.Pix[y*gray.Stride+x*4]
+			luma[y*width+x] = v
+			total += float64(v)
+		}
+	}
+	mean := total / float64(width*height)
+
+	edges := make([]float64, width*height)
+	saliency := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := float64(luma[y*width+x])
+			saliency[y*width+x] = math.Abs(v - mean)
+			if x > 0 && y > 0 {
+				dx := v - float64(luma[y*width+x-1])
+				dy := v - float64(luma[(y-1)*width+x])
+				edges[y*width+x] = math.Hypot(dx, dy)
+			}
+		}
+	}
+
+	return scoreMaps{
+		width:    width,
+		height:   height,
+		luma:     luma,
+		edges:    newSummedArea(edges, width, height),
+		saliency: newSummedArea(saliency, width, height),
+	}
+}
+
+// entropy returns Shannon entropy of luminance histogram inside a window
+func (m scoreMaps) entropy(x, y, width, height int) float64 {
+	var histogram [smartCropBins]int
+	for row := y; row < y+height; row++ {
+		for _, v := range m.luma[row*m.width+x : row*m.width+x+width] {
+			histogram[int(v)*smartCropBins/256]++
+		}
+	}
+
+	total := float64(width * height)
+	var entropy float64
+	for _, count := range histogram {
+		if count == 0 {
+			continue
+		}
+		p := float64(count) / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// summedArea is an integral image for constant time window means
+type summedArea struct {
+	width int
+	sums  []float64
+}
+
+func newSummedArea(values []float64, width, height int) summedArea {
+	stride := width + 1
+	sums := make([]float64, stride*(height+1))
+	for y := 0; y < height; y++ {
+		var rowSum float64
+		for x := 0; x < width; x++ {
+			rowSum += values[y*width+x]
+			sums[(y+1)*stride+x+1] = sums[y*stride+x+1] + rowSum
+		}
+	}
+	return summedArea{width: width, sums: sums}
+}
+
+func (s summedArea) mean(x, y, width, height int) float64 {
+	stride := s.width + 1
+	total := s.sums[(y+height)*stride+x+width] - s.sums[y*stride+x+width] - s.sums[(y+height)*stride+x] + s.sums[y*stride+x]
+	return total / float64(width*height)
+}
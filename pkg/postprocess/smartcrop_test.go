@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmartCrop(t *testing.T) {
+	img := imaging.New(400, 200, color.NRGBA{120, 140, 160, 255})
+	draw.Draw(img, image.Rect(300, 50, 380, 150), checkerImage(80, 100, 5), image.Point{}, draw.Src)
+
+	rect := SmartCrop(img, 200, 200, nil)
+
+	assert.Equal(t, 200, rect.Dx(), "Crop should keep requested width")
+	assert.Equal(t, 200, rect.Dy(), "Crop should keep requested height")
+	assert.True(t, image.Rect(300, 50, 380, 150).In(rect), "Crop should contain detailed region, got %v", rect)
+}
+
+func TestSmartCropAnchor(t *testing.T) {
+	img := imaging.New(400, 200, color.NRGBA{120, 140, 160, 255})
+
+	rect := SmartCrop(img, 100, 200, &Anchor{X: 0.0, Y: 0.5})
+
+	assert.Equal(t, 0, rect.Min.X, "Uniform image should be cropped toward anchor")
+}
+
+func TestSmartCropLargerThanImage(t *testing.T) {
+	img := imaging.New(100, 50, color.NRGBA{0, 0, 0, 255})
+
+	assert.Equal(t, img.Bounds(), SmartCrop(img, 200, 100, nil), "Oversized crop should return image bounds")
+}
@@ -0,0 +1,88 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	StackMean   = "mean"
+	StackMedian = "median"
+	StackMax    = "max"
+)
+
+// Stack combines frames of equal size pixel by pixel. Mean and median reduce
+// sensor noise, max keeps the brightest value and turns moving lights into trails.
+func Stack(frames []image.Image, mode string) (*image.NRGBA, error) {
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("no frames to stack")
+	}
+
+	bounds := frames[0].Bounds()
+	sources := make([]*image.NRGBA, len(frames))
+	for i, frame := range frames {
+		if frame.Bounds().Dx() != bounds.Dx() || frame.Bounds().Dy() != bounds.Dy() {
+			return nil, fmt.Errorf("frame %d size %dx%d differs from %dx%d", i, frame.Bounds().Dx(), frame.Bounds().Dy(), bounds.Dx(), bounds.Dy())
+		}
+		sources[i] = imaging.Clone(frame)
+	}
+
+	var combine func(values []uint8) uint8
+	switch mode {
+	case StackMean:
+		combine = meanValue
+	case StackMedian:
+		combine = medianValue
+	case StackMax:
+		combine = maxValue
+	default:
+		return nil, fmt.Errorf("unknown stack mode: %q", mode)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	values := make([]uint8, len(sources))
+	for i := range dst.Pix {
+		for j, src := range sources {
+			values[j] = src.Pix[i]
+		}
+		dst.Pix[i] = combine(values)
+	}
+
+	return dst, nil
+}
+
+func meanValue(values []uint8) uint8 {
+	sum := 0
+	for _, v := range values {
+		sum += int(v)
+	}
+	return uint8((sum + len(values)/2) / len(values))
+}
+
+func medianValue(values []uint8) uint8 {
+	// Insertion sort, stacks hold only a handful of frames
+	for i := 1; i < len(values); i++ {
+		for j := i; j > 0 && values[j] < values[j-1]; j-- {
+			values[j], values[j-1] = values[j-1], values[j]
+		}
+	}
+	middle := len(values) / 2
+	if len(values)%2 == 0 {
+		return uint8((int(values[middle-1]) + int(values[middle]) + 1) / 2)
+	}
+	return values[middle]
+}
+
+func maxValue(values []uint8) uint8 {
+	result := values[0]
+	for _, v := range values[1:] {
+		result = max(result, v)
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStack(t *testing.T) {
+	frames := []image.Image{
+		imaging.New(4, 4, color.NRGBA{10, 10, 10, 255}),
+		imaging.New(4, 4, color.NRGBA{20, 20, 20, 255}),
+		imaging.New(4, 4, color.NRGBA{240, 240, 240, 255}),
+	}
+
+	mean, err := Stack(frames, StackMean)
+	require.NoError(t, err, "Mean stack should succeed")
+	assert.Equal(t, uint8(90), mean.NRGBAAt(1, 1).R, "Mean should average frames")
+
+	median, err := Stack(frames, StackMedian)
+	require.NoError(t, err, "Median stack should succeed")
+	assert.Equal(t, uint8(20), median.NRGBAAt(1, 1).R, "Median should ignore the outlier")
+
+	maximum, err := Stack(frames, StackMax)
+	require.NoError(t, err, "Max stack should succeed")
+	assert.Equal(t, uint8(240), maximum.NRGBAAt(1, 1).R, "Max should keep brightest value")
+	assert.Equal(t, uint8(255), maximum.NRGBAAt(1, 1).A, "Alpha should be preserved")
+}
+
+func TestStackErrors(t *testing.T) {
+	_, err := Stack(nil, StackMean)
+	assert.Error(t, err, "Empty frame list should fail")
+
+	_, err = Stack([]image.Image{imaging.New(4, 4, color.Black), imaging.New(2, 2, color.Black)}, StackMean)
+	assert.Error(t, err, "Frames of different size should fail")
+
+	_, err = Stack([]image.Image{imaging.New(4, 4, color.Black)}, "sum")
+	assert.Error(t, err, "Unknown mode should fail")
+}
@@ -0,0 +1,118 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"image"
+	"math"
+)
+
+// Rec. 601 luma weights in thousandths, matching CalculateAverageBrightness
+const (
+	lumaWeightR = 299
+	lumaWeightG = 587
+	lumaWeightB = 114
+	lumaScale   = 1000
+)
+
+// MeasureOptions restricts which pixels MeasureImage samples. The zero value
+// measures every pixel of the whole image.
+type MeasureOptions struct {
+	// Region limits measurement to part of the image, empty means whole image
+	Region image.Rectangle
+	// Step samples every Step-th pixel in both directions, values below 2
+	// sample every pixel
+	Step int
+}
+
+// ImageStats summarizes luma of an image. Luma uses premultiplied color, so
+// transparent pixels count as dark.
+type ImageStats struct {
+	// Mean is the average luma in 0-1 range
+	Mean float64
+	// Histogram counts sampled pixels per 8-bit luma level
+	Histogram [256]int
+	// Samples is the number of sampled pixels
+	Samples int
+}
+
+// MeasureImage computes luma statistics in a single pass. NRGBA and RGBA
+// images are read from their pixel buffers, other types go through At.
+func MeasureImage(img image.Image, opts MeasureOptions) *ImageStats {
+	region := img.Bounds()
+	if !opts.Region.Empty() {
+		region = region.Intersect(opts.Region)
+	}
+	step := max(opts.Step, 1)
+
+	stats := &ImageStats{}
+	if region.Empty() {
+		return stats
+	}
+
+	switch src := img.(type) {
+	case *image.NRGBA:
+		stats.measure8(src.Pix, src.Stride, src.PixOffset(region.Min.X, region.Min.Y), region, step, false)
+	case *image.RGBA:
+		stats.measure8(src.Pix, src.Stride, src.PixOffset(region.Min.X, region.Min.Y), region, step, true)
+	default:
+		stats.measureGeneric(img, region, step)
+	}
+	return stats
+}
+
+// measure8 samples 8-bit RGBA pixel buffers starting at offset, which points
+// at the top left pixel of region
+func (s *ImageStats) measure8(pix []uint8, stride, offset int, region image.Rectangle, step int, premultiplied bool) {
+	var total uint64
+	for y := region.Min.Y; y < region.Max.Y; y += step {
+		row := pix[offset : offset+region.Dx()*4]
+		for i := 0; i < len(row); i += 4 * step {
+			r, g, b := uint32(row[i]), uint32(row[i+1]), uint32(row[i+2])
+			if a := uint32(row[i+3]); !premultiplied && a != 0xff {
+				r, g, b = (r*a+127)/255, (g*a+127)/255, (b*a+127)/255
+			}
+
+			luma := lumaWeightR*r + lumaWeightG*g + lumaWeightB*b
+			s.Histogram[(luma+lumaScale/2)/lumaScale]++
+			total += uint64(luma)
+			s.Samples++
+		}
+		offset += stride * step
+	}
+	s.Mean = float64(total) / float64(lumaScale*255*s.Samples)
+}
+
+func (s *ImageStats) measureGeneric(img image.Image, region image.Rectangle, step int) {
+	var total float64
+	for y := region.Min.Y; y < region.Max.Y; y += step {
+		for x := region.Min.X; x < region.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			luma := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0xffff
+			s.Histogram[int(luma*255+0.5)]++
+			total += luma
+			s.Samples++
+		}
+	}
+	s.Mean = total / float64(s.Samples)
+}
+
+// Percentile returns the luma in 0-1 range below which fraction p of the
+// sampled pixels fall
+func (s *ImageStats) Percentile(p float64) float64 {
+	if s.Samples == 0 {
+		return 0
+	}
+
+	target := max(int(math.Ceil(min(max(p, 0), 1)*float64(s.Samples))), 1)
+	count := 0
+	for level, n := range s.Histogram {
+		count += n
+		if count >= target {
+			return float64(level) / 255
+		}
+	}
+	return 1
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package postprocess
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// referenceBrightness is the per-pixel At based average MeasureImage replaces
+func referenceBrightness(img image.Image) float64 {
+	var total float64
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			total += (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 65535
+		}
+	}
+	return total / float64(bounds.Dx()*bounds.Dy())
+}
+
+func randomNRGBA(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	rand.New(rand.NewSource(1)).Read(img.Pix)
+	return img
+}
+
+func TestMeasureImageMatchesReference(t *testing.T) {
+	nrgba := randomNRGBA(33, 17)
+	rgba := image.NewRGBA(nrgba.Bounds())
+	draw.Draw(rgba, rgba.Bounds(), nrgba, image.Point{}, draw.Src)
+	gray := image.NewGray16(nrgba.Bounds())
+	draw.Draw(gray, gray.Bounds(), nrgba, image.Point{}, draw.Src)
+
+	for name, img := range map[string]image.Image{"nrgba": nrgba, "rgba": rgba, "gray16": gray} {
+		stats := MeasureImage(img, MeasureOptions{})
+		assert.InDelta(t, referenceBrightness(img), stats.Mean, 0.001, "%s: mean should match per-pixel average", name)
+		assert.Equal(t, 33*17, stats.Samples, "%s: every pixel should be sampled", name)
+	}
+}
+
+func TestMeasureImageRegionAndStep(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(img, image.Rect(4, 0, 8, 8), &image.Uniform{color.White}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 4, 8), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+
+	assert.InDelta(t, 0.5, MeasureImage(img, MeasureOptions{}).Mean, 0.001, "Half white image should average to half")
+
+	right := MeasureImage(img, MeasureOptions{Region: image.Rect(4, 2, 20, 6)})
+	assert.InDelta(t, 1.0, right.Mean, 0.001, "Region over the white half should be white")
+	assert.Equal(t, 16, right.Samples, "Region should be clipped to image bounds")
+
+	sampled := MeasureImage(img, MeasureOptions{Step: 2})
+	assert.Equal(t, 16, sampled.Samples, "Step 2 should sample a quarter of the pixels")
+	assert.InDelta(t, 0.5, sampled.Mean, 0.001, "Subsampled mean should match on regular content")
+
+	empty := MeasureImage(img, MeasureOptions{Region: image.Rect(20, 20, 30, 30)})
+	assert.Zero(t, empty.Samples, "Region outside the image should sample nothing")
+}
+
+func TestMeasureImageTransparency(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.NRGBA{255, 255, 255, 0}}, image.Point{}, draw.Src)
+
+	assert.Zero(t, MeasureImage(img, MeasureOptions{}).Mean, "Transparent pixels should count as dark")
+}
+
+func TestImageStatsPercentile(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 1))
+	for x, v := range []uint8{0, 64, 128, 255} {
+		img.SetNRGBA(x, 0, color.NRGBA{v, v, v, 255})
+	}
+	stats := MeasureImage(img, MeasureOptions{})
+
+	assert.Equal(t, 1, stats.Histogram[64], "Histogram should count each gray level")
+	assert.Equal(t, 0.0, stats.Percentile(0), "Lowest percentile should be the darkest pixel")
+	assert.InDelta(t, 64.0/255, stats.Percentile(0.5), 1e-9, "Median should be the second darkest of four pixels")
+	assert.Equal(t, 1.0, stats.Percentile(1), "Highest percentile should be the brightest pixel")
+	assert.Zero(t, (&ImageStats{}).Percentile(0.5), "Empty stats should report zero")
+}
+
+func BenchmarkMeasureImage(b *testing.B) {
+	img := randomNRGBA(3840, 2160)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MeasureImage(img, MeasureOptions{})
+	}
+}
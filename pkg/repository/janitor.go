@@ -14,8 +14,11 @@ import (
 	"github.com/TilmanGriesel/AlpineZen/pkg/util"
 )
 
+// Janitor manages disk usage below the application directory. All paths it
+// touches must resolve, after following symlinks, to a location inside RootDir.
 type Janitor struct {
 	FileType string
+	RootDir  string
 }
 
 func NewJanitor(fileType string) *Janitor {
@@ -31,7 +34,7 @@ func (j *Janitor) DeepClean(directory string) error {
 		return err
 	}
 
-	info, err := os.Stat(directory)
+	info, err := os.Lstat(directory)
 	if os.IsNotExist(err) {
 		return fmt.Errorf("directory does not exist: %s", directory)
 	}
@@ -39,6 +42,10 @@ func (j *Janitor) DeepClean(directory string) error {
 		return fmt.Errorf("error checking directory: %v", err)
 	}
 
+	if info.Mode()&os.ModeSymlink != 0 {
+		return fmt.Errorf("refusing to remove symlink: %s", directory)
+	}
+
 	if !info.IsDir() {
 		return fmt.Errorf("%s is not a directory", directory)
 	}
@@ -100,10 +107,68 @@ func (j *Janitor) WipeThrough(directory string, maxFiles int) error {
 	return nil
 }
 
+// validatePath checks that path resolves to a location strictly inside root
+// directory. Symlinks are resolved on both sides, so neither '..' segments nor
+// links pointing out of root directory pass.
 func (j *Janitor) validatePath(path string) (bool, error) {
-	appDirName := util.GetAppDirName()
-	if !strings.Contains(path, appDirName) {
-		return false, fmt.Errorf("directory path must include '%s': %s", appDirName, path)
+	root, err := j.rootDir()
+	if err != nil {
+		return false, err
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, fmt.Errorf("error getting absolute path: %v", err)
+	}
+
+	resolved, err := resolveExisting(absPath)
+	if err != nil {
+		return false, err
+	}
+
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false, fmt.Errorf("path must be inside '%s': %s", root, path)
 	}
 	return true, nil
 }
+
+func (j *Janitor) rootDir() (string, error) {
+	root := j.RootDir
+	if root == "" {
+		appDirPath, err := util.GetAppDirPath()
+		if err != nil {
+			return "", fmt.Errorf("failed to get application directory path: %w", err)
+		}
+		root = appDirPath
+	}
+
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("error getting absolute path: %v", err)
+	}
+	return resolveExisting(absRoot)
+}
+
+// resolveExisting evaluates symlinks of the longest existing prefix of path
+// and appends the remaining, not yet existing, elements
+func resolveExisting(path string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", fmt.Errorf("error resolving path: %v", err)
+	}
+
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+
+	resolvedParent, err := resolveExisting(parent)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolvedParent, filepath.Base(path)), nil
+}
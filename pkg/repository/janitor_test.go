@@ -0,0 +1,265 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func skipWithoutSymlinks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Symlinks need elevated privileges on Windows")
+	}
+}
+
+// newTestRoot creates an application directory and a sibling directory outside it
+func newTestRoot(t *testing.T) (root, outside string) {
+	base := t.TempDir()
+	root = filepath.Join(base, ".alpinezen_wallpaper")
+	outside = filepath.Join(base, "home")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "files"), 0750), "Creating root should succeed")
+	require.NoError(t, os.MkdirAll(outside, 0750), "Creating outside directory should succeed")
+	return root, outside
+}
+
+func writeTestFile(t *testing.T, path string, size int, modTime time.Time) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750), "Creating parent directory should succeed")
+	require.NoError(t, os.WriteFile(path, make([]byte, size), 0600), "Writing test file should succeed")
+	require.NoError(t, os.Chtimes(path, modTime, modTime), "Setting file time should succeed")
+}
+
+func TestValidatePath(t *testing.T) {
+	root, outside := newTestRoot(t)
+	janitor := &Janitor{RootDir: root}
+
+	tests := []struct {
+		name  string
+		path  string
+		valid bool
+	}{
+		{"directory inside root", filepath.Join(root, "files"), true},
+		{"missing directory inside root", filepath.Join(root, "files", "missing"), true},
+		{"root itself", root, false},
+		{"parent of root", filepath.Dir(root), false},
+		{"sibling sharing root prefix", root + "_evil", false},
+		{"prefix trick climbing out", root + "_evil/../../home", false},
+		{"request example", "/tmp/.alpinezen_wallpaper_evil/../../home", false},
+		{"dot segments leaving root", filepath.Join(root, "files", "..", "..", "home"), false},
+		{"unrelated directory", outside, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			valid, err := janitor.validatePath(tt.path)
+			assert.Equal(t, tt.valid, valid, "validatePath(%s) should report %v", tt.path, tt.valid)
+			if !tt.valid {
+				assert.Error(t, err, "Rejected paths should come with an error")
+			}
+		})
+	}
+}
+
+func TestValidatePathSymlinks(t *testing.T) {
+	skipWithoutSymlinks(t)
+	root, outside := newTestRoot(t)
+
+	escape := filepath.Join(root, "files", "escape")
+	require.NoError(t, os.Symlink(outside, escape), "Creating symlink should succeed")
+
+	linkedRoot := filepath.Join(filepath.Dir(root), "linked_root")
+	require.NoError(t, os.Symlink(root, linkedRoot), "Creating root symlink should succeed")
+
+	tests := []struct {
+		name    string
+		rootDir string
+		path    string
+		valid   bool
+	}{
+		{"symlink escaping root", root, escape, false},
+		{"path below escaping symlink", root, filepath.Join(escape, "data"), false},
+		{"symlinked root, real path", linkedRoot, filepath.Join(root, "files"), true},
+		{"symlinked root, linked path", linkedRoot, filepath.Join(linkedRoot, "files"), true},
+		{"real root, linked path", root, filepath.Join(linkedRoot, "files"), true},
+		{"symlinked root, outside path", linkedRoot, outside, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			janitor := &Janitor{RootDir: tt.rootDir}
+			valid, _ := janitor.validatePath(tt.path)
+			assert.Equal(t, tt.valid, valid, "validatePath(%s) with root %s should report %v", tt.path, tt.rootDir, tt.valid)
+		})
+	}
+}
+
+func TestDeepClean(t *testing.T) {
+	root, outside := newTestRoot(t)
+	janitor := &Janitor{RootDir: root}
+	writeTestFile(t, filepath.Join(outside, "keep.txt"), 1, time.Now())
+
+	target := filepath.Join(root, "files", "abc")
+	writeTestFile(t, filepath.Join(target, "image.png"), 1, time.Now())
+	require.NoError(t, janitor.DeepClean(target), "Directory inside root should be removed")
+	assert.NoDirExists(t, target, "Removed directory should be gone")
+
+	assert.Error(t, janitor.DeepClean(root+"_evil/../home"), "Paths climbing out of root should be refused")
+	assert.Error(t, janitor.DeepClean(root), "Root itself should be refused")
+	assert.FileExists(t, filepath.Join(outside, "keep.txt"), "Files outside root should survive")
+}
+
+func TestDeepCleanRefusesSymlinks(t *testing.T) {
+	skipWithoutSymlinks(t)
+	root, outside := newTestRoot(t)
+	janitor := &Janitor{RootDir: root}
+	writeTestFile(t, filepath.Join(outside, "keep.txt"), 1, time.Now())
+
+	inner := filepath.Join(root, "files", "inner")
+	require.NoError(t, os.MkdirAll(inner, 0750), "Creating directory should succeed")
+	link := filepath.Join(root, "files", "link")
+	require.NoError(t, os.Symlink(inner, link), "Creating symlink should succeed")
+	escape := filepath.Join(root, "files", "escape")
+	require.NoError(t, os.Symlink(outside, escape), "Creating symlink should succeed")
+
+	assert.Error(t, janitor.DeepClean(link), "Symlinks inside root should not be removed")
+	assert.DirExists(t, inner, "Symlink target should survive")
+	assert.Error(t, janitor.DeepClean(escape), "Symlinks leaving root should not be removed")
+	assert.FileExists(t, filepath.Join(outside, "keep.txt"), "Symlink target outside root should survive")
+}
+
+func TestApplyRetention(t *testing.T) {
+	root, _ := newTestRoot(t)
+	janitor := &Janitor{FileType: ".jpg", RootDir: root}
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+
+	newFrames := func(t *testing.T) string {
+		dir := filepath.Join(root, "archive", t.Name())
+		// Two frames per hour going back three days, newest first
+		for i := 0; i < 6*24; i++ {
+			modTime := now.Add(-time.Duration(i) * 30 * time.Minute)
+			writeTestFile(t, filepath.Join(dir, modTime.Format("20060102_150405")+".jpg"), 10, modTime)
+		}
+		writeTestFile(t, filepath.Join(dir, "notes.txt"), 10, now.Add(-100*24*time.Hour))
+		return dir
+	}
+	count := func(t *testing.T, dir string) int {
+		files, err := janitor.listFiles(dir)
+		require.NoError(t, err, "Listing files should succeed")
+		return len(files)
+	}
+
+	tests := []struct {
+		name      string
+		policy    RetentionPolicy
+		remaining int
+	}{
+		{"disabled", RetentionPolicy{}, 144},
+		{"max age", RetentionPolicy{MaxAge: 24 * time.Hour}, 49},
+		{"max files", RetentionPolicy{MaxFiles: 10}, 10},
+		{"max bytes", RetentionPolicy{MaxBytes: 95}, 9},
+		{"hourly after", RetentionPolicy{HourlyAfter: 24 * time.Hour}, 49 + 48},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := newFrames(t)
+			removed, err := janitor.ApplyRetention(dir, tt.policy, now)
+			require.NoError(t, err, "Retention should succeed")
+			assert.Equal(t, tt.remaining, count(t, dir), "Remaining frames should match policy")
+			assert.Equal(t, 144-tt.remaining, removed, "Removed count should match deleted files")
+			assert.FileExists(t, filepath.Join(dir, "notes.txt"), "Files of other types should be ignored")
+		})
+	}
+
+	_, err := janitor.ApplyRetention(filepath.Dir(root), RetentionPolicy{MaxFiles: 1}, now)
+	assert.Error(t, err, "Retention outside root should be refused")
+}
+
+func TestCollectOrphans(t *testing.T) {
+	root, _ := newTestRoot(t)
+	janitor := &Janitor{RootDir: root}
+	files := filepath.Join(root, "files")
+	now := time.Now()
+	old := now.Add(-30 * 24 * time.Hour)
+
+	writeTestFile(t, filepath.Join(files, "live", "proc", "a.png"), 100, old)
+	writeTestFile(t, filepath.Join(files, "stale", "proc", "a.png"), 200, old)
+	writeTestFile(t, filepath.Join(files, "recent", "proc", "a.png"), 300, now)
+	for _, dir := range []string{"live", "live/proc", "stale", "stale/proc"} {
+		require.NoError(t, os.Chtimes(filepath.Join(files, dir), old, old), "Setting directory time should succeed")
+	}
+
+	removed, err := janitor.CollectOrphans(files, []string{"live"}, 7*24*time.Hour, now)
+	require.NoError(t, err, "Collecting orphans should succeed")
+
+	require.Len(t, removed, 1, "Only the stale directory should be collected")
+	assert.Equal(t, "stale", removed[0].Name, "Stale hash should be reported")
+	assert.Equal(t, int64(200), removed[0].Bytes, "Reported size should match removed files")
+	assert.NoDirExists(t, filepath.Join(files, "stale"), "Stale hash directory should be removed")
+	assert.DirExists(t, filepath.Join(files, "live"), "Live hash directory should be kept")
+	assert.DirExists(t, filepath.Join(files, "recent"), "Recently modified directory should be kept")
+}
+
+func TestCollectOrphansSkipsSymlinks(t *testing.T) {
+	skipWithoutSymlinks(t)
+	root, outside := newTestRoot(t)
+	janitor := &Janitor{RootDir: root}
+	files := filepath.Join(root, "files")
+	writeTestFile(t, filepath.Join(outside, "keep.txt"), 1, time.Now())
+	require.NoError(t, os.Symlink(outside, filepath.Join(files, "escape")), "Creating symlink should succeed")
+
+	removed, err := janitor.CollectOrphans(files, nil, 0, time.Now().Add(time.Hour))
+	require.NoError(t, err, "Collecting orphans should succeed")
+	assert.Empty(t, removed, "Symlinks should not be collected")
+	assert.FileExists(t, filepath.Join(outside, "keep.txt"), "Symlink target should survive")
+}
+
+func TestEnforceQuota(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		maxBytes  int64
+		protected []string
+		removed   []string
+	}{
+		{"disabled", 0, nil, nil},
+		{"within quota", 600, nil, nil},
+		{"oldest first", 300, nil, []string{"oldest", "older"}},
+		{"protected kept", 300, []string{"oldest"}, []string{"older", "newest"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, _ := newTestRoot(t)
+			janitor := &Janitor{RootDir: root}
+			files := filepath.Join(root, "files")
+
+			for i, name := range []string{"oldest", "older", "newest"} {
+				modTime := now.Add(time.Duration(i-3) * time.Hour)
+				writeTestFile(t, filepath.Join(files, name, "a.png"), 200, modTime)
+				require.NoError(t, os.Chtimes(filepath.Join(files, name), modTime, modTime), "Setting directory time should succeed")
+			}
+
+			removed, err := janitor.EnforceQuota(files, tt.maxBytes, tt.protected)
+			require.NoError(t, err, "Enforcing quota should succeed")
+
+			var names []string
+			for _, dirUsage := range removed {
+				names = append(names, dirUsage.Name)
+				assert.NoDirExists(t, dirUsage.Path, "Evicted directory should be removed")
+			}
+			assert.Equal(t, tt.removed, names, "Least recently modified directories should be evicted")
+			for _, name := range tt.protected {
+				assert.DirExists(t, filepath.Join(files, name), "Protected directory should be kept")
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package repository
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// DirectoryUsage is disk usage of a single per-URL-hash directory
+type DirectoryUsage struct {
+	Name     string
+	Path     string
+	Bytes    int64
+	Files    int
+	Modified time.Time
+}
+
+// QuotaPolicy limits disk usage of per source directories. Limits are
+// disabled when zero.
+type QuotaPolicy struct {
+	MaxBytes     int64
+	OrphanMaxAge time.Duration
+}
+
+// Usage reports sizes of all subdirectories of directory, largest first.
+// Symlinks are counted as links and never followed.
+func (j *Janitor) Usage(directory string) ([]DirectoryUsage, error) {
+	validPath, err := j.validatePath(directory)
+	if !validPath {
+		return nil, fmt.Errorf("invalid path: %w", err)
+	}
+
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var usage []DirectoryUsage
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dirUsage, err := measureDirectory(filepath.Join(directory, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		dirUsage.Name = entry.Name()
+		usage = append(usage, dirUsage)
+	}
+
+	sort.Slice(usage, func(a, b int) bool {
+		return usage[a].Bytes > usage[b].Bytes
+	})
+
+	return usage, nil
+}
+
+func measureDirectory(path string) (DirectoryUsage, error) {
+	usage := DirectoryUsage{Path: path}
+
+	err := filepath.WalkDir(path, func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if info.ModTime().After(usage.Modified) {
+			usage.Modified = info.ModTime()
+		}
+		if info.Mode().IsRegular() {
+			usage.Bytes += info.Size()
+			usage.Files++
+		}
+		return nil
+	})
+	if err != nil {
+		return usage, fmt.Errorf("failed to measure directory %s: %w", path, err)
+	}
+
+	return usage, nil
+}
+
+// CollectOrphans removes subdirectories of directory that are not listed in
+// active and were not modified within minAge. It returns the removed entries.
+func (j *Janitor) CollectOrphans(directory string, active []string, minAge time.Duration, now time.Time) ([]DirectoryUsage, error) {
+	usage, err := j.Usage(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	activeSet := make(map[string]bool, len(active))
+	for _, name := range active {
+		activeSet[name] = true
+	}
+
+	var removed []DirectoryUsage
+	for _, dirUsage := range usage {
+		if activeSet[dirUsage.Name] || now.Sub(dirUsage.Modified) < minAge {
+			continue
+		}
+
+		if err := j.DeepClean(dirUsage.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove orphaned directory: %w", err)
+		}
+		removed = append(removed, dirUsage)
+	}
+
+	return removed, nil
+}
+
+// EnforceQuota removes least recently modified subdirectories of directory
+// until their total size fits maxBytes. Directories listed in protected are
+// never removed. It returns the removed entries.
+func (j *Janitor) EnforceQuota(directory string, maxBytes int64, protected []string) ([]DirectoryUsage, error) {
+	if maxBytes <= 0 {
+		return nil, nil
+	}
+
+	usage, err := j.Usage(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	protectedSet := make(map[string]bool, len(protected))
+	for _, name := range protected {
+		protectedSet[name] = true
+	}
+
+	var totalBytes int64
+	for _, dirUsage := range usage {
+		totalBytes += dirUsage.Bytes
+	}
+
+	// Oldest first
+	sort.Slice(usage, func(a, b int) bool {
+		return usage[a].Modified.Before(usage[b].Modified)
+	})
+
+	var removed []DirectoryUsage
+	for _, dirUsage := range usage {
+		if totalBytes <= maxBytes {
+			break
+		}
+		if protectedSet[dirUsage.Name] {
+			continue
+		}
+
+		if err := j.DeepClean(dirUsage.Path); err != nil {
+			return removed, fmt.Errorf("failed to evict directory: %w", err)
+		}
+		totalBytes -= dirUsage.Bytes
+		removed = append(removed, dirUsage)
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package repository
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RetentionPolicy limits growth of a directory of timestamped files. Zero
+// values disable the respective rule.
+type RetentionPolicy struct {
+	MaxAge      time.Duration
+	MaxBytes    int64
+	MaxFiles    int
+	HourlyAfter time.Duration
+}
+
+type retainedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Enabled reports whether any retention rule is set
+func (p RetentionPolicy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxBytes > 0 || p.MaxFiles > 0 || p.HourlyAfter > 0
+}
+
+// ApplyRetention removes files in directory violating policy and returns the
+// number of removed files. Rules are applied in order: max age, hourly
+// thinning of older files, max file count and max total size. Count and size
+// limits remove oldest files first.
+func (j *Janitor) ApplyRetention(directory string, policy RetentionPolicy, now time.Time) (int, error) {
+	validPath, err := j.validatePath(directory)
+	if !validPath {
+		return 0, fmt.Errorf("invalid path: %w", err)
+	}
+
+	if !policy.Enabled() {
+		return 0, nil
+	}
+
+	files, err := j.listFiles(directory)
+	if err != nil {
+		return 0, err
+	}
+
+	// Newest first
+	sort.Slice(files, func(a, b int) bool {
+		return files[a].modTime.After(files[b].modTime)
+	})
+
+	var keep, remove []retainedFile
+	seenHours := make(map[time.Time]bool)
+	var totalBytes int64
+
+	for _, file := range files {
+		age := now.Sub(file.modTime)
+
+		if policy.MaxAge > 0 && age > policy.MaxAge {
+			remove = append(remove, file)
+			continue
+		}
+
+		if policy.HourlyAfter > 0 && age > policy.HourlyAfter {
+			hour := file.modTime.Truncate(time.Hour)
+			if seenHours[hour] {
+				remove = append(remove, file)
+				continue
+			}
+			seenHours[hour] = true
+		}
+
+		if policy.MaxFiles > 0 && len(keep) >= policy.MaxFiles {
+			remove = append(remove, file)
+			continue
+		}
+
+		if policy.MaxBytes > 0 && totalBytes+file.size > policy.MaxBytes {
+			remove = append(remove, file)
+			continue
+		}
+
+		totalBytes += file.size
+		keep = append(keep, file)
+	}
+
+	removed := 0
+	for _, file := range remove {
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove file %s: %w", file.path, err)
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// listFiles returns regular files in directory matching janitor file type
+func (j *Janitor) listFiles(directory string) ([]retainedFile, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+
+	var files []retainedFile
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), j.FileType) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		files = append(files, retainedFile{
+			path:    filepath.Join(directory, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+
+	return files, nil
+}
@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package sanitizer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+)
+
+const (
+	maxICCProfileSize = 4 * 1024 * 1024
+
+	exifTagExifIFD      = 0x8769
+	exifTagInteropIFD   = 0xA005
+	exifTagColorSpace   = 0xA001
+	exifTagInteropIndex = 0x0001
+	exifColorSpaceSRGB  = 1
+)
+
+// DetectColorSpace reads color space hints from an encoded JPEG or PNG. An
+// embedded ICC profile wins over EXIF and PNG sRGB markers. It returns an
+// empty string when the file carries no recognizable hint, which callers
+// should treat as sRGB.
+func DetectColorSpace(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return jpegColorSpace(data)
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return pngColorSpace(data)
+	default:
+		return ""
+	}
+}
+
+func jpegColorSpace(data []byte) string {
+	var icc [][]byte
+	exif := ""
+
+	for pos := 2; pos+4 <= len(data); {
+		if data[pos] != 0xFF {
+			break
+		}
+		marker := data[pos+1]
+		if marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			pos += 2
+			continue
+		}
+		// Metadata segments precede the scan data
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+		segment := data[pos+4 : end]
+
+		switch {
+		case marker == 0xE2 && bytes.HasPrefix(segment, []byte("ICC_PROFILE\x00")) && len(segment) > 14:
+			sequence, count := int(segment[12]), int(segment[13])
+			if sequence >= 1 && sequence <= count {
+				if len(icc) < count {
+					icc = append(icc, make([][]byte, count-len(icc))...)
+				}
+				icc[sequence-1] = segment[14:]
+			}
+		case marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")):
+			exif = exifColorSpace(segment[6:])
+		}
+
+		pos = end
+	}
+
+	if len(icc) > 0 {
+		if space := postprocess.ICCColorSpace(bytes.Join(icc, nil)); space != "" {
+			return space
+		}
+	}
+	return exif
+}
+
+// exifColorSpace reads the EXIF ColorSpace tag and the interoperability index,
+// which marks Adobe RGB files as uncalibrated with index R03
+func exifColorSpace(tiff []byte) string {
+	if len(tiff) < 8 {
+		return ""
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return ""
+	}
+
+	// readIFD returns value fields of IFD entries keyed by tag
+	readIFD := func(offset uint32) map[uint16][]byte {
+		entries := make(map[uint16][]byte)
+		if int(offset)+2 > len(tiff) {
+			return entries
+		}
+		count := int(order.Uint16(tiff[offset:]))
+		for i := 0; i < count; i++ {
+			entry := int(offset) + 2 + i*12
+			if entry+12 > len(tiff) {
+				break
+			}
+			entries[order.Uint16(tiff[entry:])] = tiff[entry+8 : entry+12]
+		}
+		return entries
+	}
+
+	root := readIFD(order.Uint32(tiff[4:]))
+	exifPointer, ok := root[exifTagExifIFD]
+	if !ok {
+		return ""
+	}
+	exifIFD := readIFD(order.Uint32(exifPointer))
+
+	if value, ok := exifIFD[exifTagColorSpace]; ok && order.Uint16(value) == exifColorSpaceSRGB {
+		return postprocess.ColorSpaceSRGB
+	}
+	if interopPointer, ok := exifIFD[exifTagInteropIFD]; ok {
+		interop := readIFD(order.Uint32(interopPointer))
+		if index, ok := interop[exifTagInteropIndex]; ok && string(index[:3]) == "R03" {
+			return postprocess.ColorSpaceAdobeRGB
+		}
+	}
+	return ""
+}
+
+func pngColorSpace(data []byte) string {
+	for pos := 8; pos+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			break
+		}
+		chunk := data[pos+8 : pos+8+length]
+
+		switch chunkType {
+		case "iCCP":
+			nameEnd := bytes.IndexByte(chunk, 0)
+			if nameEnd < 0 || nameEnd+2 > len(chunk) {
+				return ""
+			}
+			reader, err := zlib.NewReader(bytes.NewReader(chunk[nameEnd+2:]))
+			if err != nil {
+				return ""
+			}
+			profile, err := io.ReadAll(io.LimitReader(reader, maxICCProfileSize))
+			if err != nil {
+				return ""
+			}
+			return postprocess.ICCColorSpace(profile)
+		case "sRGB":
+			return postprocess.ColorSpaceSRGB
+		case "IDAT":
+			return ""
+		}
+
+		pos = end
+	}
+	return ""
+}
@@ -5,24 +5,41 @@
 package sanitizer
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
+// ImageInfo describes a sanitized image. ColorSpace holds the hint found in
+// metadata that sanitizing strips, empty when the file had none.
+type ImageInfo struct {
+	Format     string
+	ColorSpace string
+}
+
 func SanitizeImage(filePath string) error {
+	_, err := SanitizeImageInfo(filePath)
+	return err
+}
+
+// SanitizeImageInfo sanitizes an image like SanitizeImage and reports its
+// format and color space hint
+func SanitizeImageInfo(filePath string) (ImageInfo, error) {
+	var info ImageInfo
 	if filePath == "" {
-		return fmt.Errorf("file path cannot be empty")
+		return info, fmt.Errorf("file path cannot be empty")
 	}
 
 	cleanedPath := filepath.Clean(filePath)
 	file, err := os.Open(cleanedPath)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return info, fmt.Errorf("failed to open file: %w", err)
 	}
 
 	// Ensure the input file is closed properly
@@ -35,39 +52,44 @@ func SanitizeImage(filePath string) error {
 	// Verify file size; avoid processing very large files
 	fileInfo, err := file.Stat()
 	if err != nil {
-		return fmt.Errorf("failed to get file info: %w", err)
+		return info, fmt.Errorf("failed to get file info: %w", err)
 	}
 
 	const maxSize = 10 * 1024 * 1024 // 10 MB limit
 	if fileInfo.Size() > maxSize {
-		return fmt.Errorf("file is too large: %d bytes", fileInfo.Size())
+		return info, fmt.Errorf("file is too large: %d bytes", fileInfo.Size())
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return info, fmt.Errorf("failed to read file: %w", err)
 	}
 
 	// Ensure it's a valid image format
-	img, format, err := image.Decode(file)
+	img, format, err := image.Decode(bytes.NewReader(data))
 	if err != nil {
-		return fmt.Errorf("invalid image format or corrupt image: %w", err)
+		return info, fmt.Errorf("invalid image format or corrupt image: %w", err)
 	}
 
 	// Validate decoded image to ensure it's not nil
 	if img == nil {
-		return fmt.Errorf("decoded image is nil")
+		return info, fmt.Errorf("decoded image is nil")
 	}
 
 	// Close the input file before deleting
 	if err := file.Close(); err != nil {
-		return fmt.Errorf("failed to close input file: %w", err)
+		return info, fmt.Errorf("failed to close input file: %w", err)
 	}
 
 	// Delete original file
 	if err := os.Remove(cleanedPath); err != nil {
-		return fmt.Errorf("failed to delete the original file: %w", err)
+		return info, fmt.Errorf("failed to delete the original file: %w", err)
 	}
 
 	// Create sanitized file
 	outFile, err := os.Create(cleanedPath)
 	if err != nil {
-		return fmt.Errorf("failed to create new file: %w", err)
+		return info, fmt.Errorf("failed to create new file: %w", err)
 	}
 
 	// Ensure the output file is closed properly
@@ -81,21 +103,24 @@ func SanitizeImage(filePath string) error {
 	switch strings.ToLower(format) {
 	case "jpeg":
 		if encodeErr := jpeg.Encode(outFile, img, nil); encodeErr != nil {
-			return fmt.Errorf("failed to encode JPEG image: %w", encodeErr)
+			return info, fmt.Errorf("failed to encode JPEG image: %w", encodeErr)
 		}
 	case "png":
 		if encodeErr := png.Encode(outFile, img); encodeErr != nil {
-			return fmt.Errorf("failed to encode PNG image: %w", encodeErr)
+			return info, fmt.Errorf("failed to encode PNG image: %w", encodeErr)
 		}
 	default:
-		return fmt.Errorf("unsupported image format: %s", format)
+		return info, fmt.Errorf("unsupported image format: %s", format)
 	}
 
+	info.Format = format
+	info.ColorSpace = DetectColorSpace(data)
+
 	if err := outFile.Sync(); err != nil {
-		return fmt.Errorf("failed to sync new file to disk: %w", err)
+		return info, fmt.Errorf("failed to sync new file to disk: %w", err)
 	}
 
-	return nil
+	return info, nil
 }
 
 func SanitizeArchivePath(dir, target string) (string, error) {
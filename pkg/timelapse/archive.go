@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package timelapse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+const (
+	ArchiveTimeLayout = "20060102_150405"
+)
+
+var archiveNamePattern = regexp.MustCompile(`_(\d{8}_\d{6})\.jpg$`)
+
+// Frame is an archived wallpaper together with its capture time
+type Frame struct {
+	Path string
+	Time time.Time
+}
+
+// SelectFrames returns archived frames captured within [from, to], sorted by time.
+// Frame times are parsed from archive file names in local time.
+func SelectFrames(archiveDir string, from, to time.Time) ([]Frame, error) {
+	entries, err := os.ReadDir(filepath.Clean(archiveDir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive directory: %w", err)
+	}
+
+	var frames []Frame
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		matches := archiveNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			continue
+		}
+
+		frameTime, err := time.ParseInLocation(ArchiveTimeLayout, matches[1], time.Local)
+		if err != nil {
+			continue
+		}
+
+		if frameTime.Before(from) || frameTime.After(to) {
+			continue
+		}
+
+		frames = append(frames, Frame{
+			Path: filepath.Join(archiveDir, entry.Name()),
+			Time: frameTime,
+		})
+	}
+
+	sort.Slice(frames, func(i, j int) bool {
+		return frames[i].Time.Before(frames[j].Time)
+	})
+
+	return frames, nil
+}
+
+// ThinFrames keeps only frames at least minInterval apart
+func ThinFrames(frames []Frame, minInterval time.Duration) []Frame {
+	if minInterval <= 0 || len(frames) == 0 {
+		return frames
+	}
+
+	thinned := []Frame{frames[0]}
+	for _, frame := range frames[1:] {
+		if frame.Time.Sub(thinned[len(thinned)-1].Time) >= minInterval {
+			thinned = append(thinned, frame)
+		}
+	}
+	return thinned
+}
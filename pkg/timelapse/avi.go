@@ -0,0 +1,206 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package timelapse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+)
+
+const (
+	aviKeyFrame  = 0x10
+	aviHasIndex  = 0x10
+	headerLength = 224
+)
+
+// MJPEGWriter writes Motion JPEG frames into an AVI container. All frames
+// must share dimensions of first frame.
+type MJPEGWriter struct {
+	w       io.WriteSeeker
+	fps     int
+	quality int
+	width   int
+	height  int
+	frames  int
+	offsets []uint32
+	sizes   []uint32
+	moviPos int64
+	written int64
+	started bool
+}
+
+func NewMJPEGWriter(w io.WriteSeeker, fps, quality int) *MJPEGWriter {
+	return &MJPEGWriter{
+		w:       w,
+		fps:     fps,
+		quality: quality,
+	}
+}
+
+func (m *MJPEGWriter) AddFrame(img image.Image) error {
+	bounds := img.Bounds()
+	if !m.started {
+		m.width = bounds.Dx()
+		m.height = bounds.Dy()
+		if err := m.writeHeader(); err != nil {
+			return err
+		}
+		m.started = true
+	} else if bounds.Dx() != m.width || bounds.Dy() != m.height {
+		return fmt.Errorf("frame size %dx%d differs from video size %dx%d", bounds.Dx(), bounds.Dy(), m.width, m.height)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: m.quality}); err != nil {
+		return fmt.Errorf("failed to encode frame: %w", err)
+	}
+
+	// Offsets in idx1 are relative to 'movi' fourcc
+	m.offsets = append(m.offsets, uint32(m.written-m.moviPos))
+	m.sizes = append(m.sizes, uint32(buf.Len()))
+	if err := m.writeChunk("00dc", buf.Bytes()); err != nil {
+		return err
+	}
+
+	m.frames++
+	return nil
+}
+
+// Close writes index and patches header sizes. It does not close underlying writer.
+func (m *MJPEGWriter) Close() error {
+	if !m.started {
+		return fmt.Errorf("no frames written")
+	}
+
+	moviSize := m.written - m.moviPos
+
+	index := new(bytes.Buffer)
+	for i := range m.offsets {
+		index.WriteString("00dc")
+		writeLE(index, uint32(aviKeyFrame), m.offsets[i], m.sizes[i])
+	}
+	if err := m.writeChunk("idx1", index.Bytes()); err != nil {
+		return err
+	}
+
+	patches := []struct {
+		pos   int64
+		value uint32
+	}{
+		{4, uint32(m.written - 8)},        // RIFF size
+		{48, uint32(m.frames)},            // avih total frames
+		{140, uint32(m.frames)},           // strh length
+		{m.moviPos - 4, uint32(moviSize)}, // movi list size
+	}
+	for _, patch := range patches {
+		if _, err := m.w.Seek(patch.pos, io.SeekStart); err != nil {
+			return err
+		}
+		if err := binary.Write(m.w, binary.LittleEndian, patch.value); err != nil {
+			return err
+		}
+	}
+
+	_, err := m.w.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (m *MJPEGWriter) writeHeader() error {
+	header := new(bytes.Buffer)
+	microSecPerFrame := uint32(1000000 / m.fps)
+	frameBytes := uint32(m.width * m.height * 3)
+
+	header.WriteString("RIFF")
+	writeLE(header, uint32(0))
+	header.WriteString("AVI ")
+
+	header.WriteString("LIST")
+	writeLE(header, uint32(192))
+	header.WriteString("hdrl")
+
+	header.WriteString("avih")
+	writeLE(header, uint32(56),
+		microSecPerFrame,
+		frameBytes*uint32(m.fps),
+		uint32(0),
+		uint32(aviHasIndex),
+		uint32(0), // total frames, patched on close
+		uint32(0),
+		uint32(1),
+		frameBytes,
+		uint32(m.width),
+		uint32(m.height),
+		uint32(0), uint32(0), uint32(0), uint32(0))
+
+	header.WriteString("LIST")
+	writeLE(header, uint32(116))
+	header.WriteString("strl")
+
+	header.WriteString("strh")
+	writeLE(header, uint32(56))
+	header.WriteString("vids")
+	header.WriteString("MJPG")
+	writeLE(header,
+		uint32(0),
+		uint16(0), uint16(0),
+		uint32(0),
+		uint32(1),
+		uint32(m.fps),
+		uint32(0),
+		uint32(0), // length in frames, patched on close
+		frameBytes,
+		uint32(0xFFFFFFFF),
+		uint32(0),
+		uint16(0), uint16(0), uint16(m.width), uint16(m.height))
+
+	header.WriteString("strf")
+	writeLE(header, uint32(40),
+		uint32(40),
+		int32(m.width),
+		int32(m.height),
+		uint16(1),
+		uint16(24))
+	header.WriteString("MJPG")
+	writeLE(header, frameBytes, int32(0), int32(0), uint32(0), uint32(0))
+
+	header.WriteString("LIST")
+	writeLE(header, uint32(0)) // movi size, patched on close
+	header.WriteString("movi")
+
+	if header.Len() != headerLength {
+		return fmt.Errorf("unexpected AVI header length: %d", header.Len())
+	}
+
+	if _, err := m.w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	m.written = int64(header.Len())
+	m.moviPos = m.written - 4
+	return nil
+}
+
+func (m *MJPEGWriter) writeChunk(fourcc string, data []byte) error {
+	chunk := new(bytes.Buffer)
+	chunk.WriteString(fourcc)
+	writeLE(chunk, uint32(len(data)))
+	chunk.Write(data)
+	if len(data)%2 == 1 {
+		chunk.WriteByte(0)
+	}
+
+	n, err := m.w.Write(chunk.Bytes())
+	m.written += int64(n)
+	return err
+}
+
+func writeLE(buf *bytes.Buffer, values ...interface{}) {
+	for _, value := range values {
+		_ = binary.Write(buf, binary.LittleEndian, value)
+	}
+}
@@ -0,0 +1,294 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package timelapse
+
+import (
+	"bufio"
+	"compress/lzw"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"sort"
+
+	"github.com/disintegration/imaging"
+)
+
+const (
+	paletteSize       = 256
+	paletteSampleSize = 64
+)
+
+// PaletteSampler collects downscaled color samples of frames one at a time,
+// so a shared palette can be built without keeping frames in memory. A shared
+// palette keeps colors stable between frames and avoids flicker.
+type PaletteSampler struct {
+	samples []color.NRGBA
+}
+
+// Add samples a downscaled copy of frame
+func (p *PaletteSampler) Add(frame image.Image) {
+	small := imaging.Resize(frame, paletteSampleSize, 0, imaging.Box)
+	for i := 0; i < len(small.Pix); i += 4 {
+		p.samples = append(p.samples, color.NRGBA{small.Pix[i], small.Pix[i+1], small.Pix[i+2], 255})
+	}
+}
+
+// BuildPalette derives a shared palette for all frames with median cut
+// quantization over downscaled copies of each frame
+func BuildPalette(frames []image.Image, size int) color.Palette {
+	sampler := &PaletteSampler{}
+	for _, frame := range frames {
+		sampler.Add(frame)
+	}
+	return sampler.Palette(size)
+}
+
+// Palette splits collected samples into at most size colors with median cut
+func (p *PaletteSampler) Palette(size int) color.Palette {
+	samples := p.samples
+	if len(samples) == 0 {
+		return color.Palette{color.Black}
+	}
+
+	buckets := [][]color.NRGBA{samples}
+	for len(buckets) < size {
+		index, channel := widestBucket(buckets)
+		if index < 0 {
+			break
+		}
+
+		bucket := buckets[index]
+		sort.Slice(bucket, func(i, j int) bool {
+			return channelValue(bucket[i], channel) < channelValue(bucket[j], channel)
+		})
+		middle := len(bucket) / 2
+		buckets[index] = bucket[:middle]
+		buckets = append(buckets, bucket[middle:])
+	}
+
+	palette := make(color.Palette, 0, len(buckets))
+	for _, bucket := range buckets {
+		palette = append(palette, averageColor(bucket))
+	}
+	return palette
+}
+
+// widestBucket returns splittable bucket with largest channel range and that channel
+func widestBucket(buckets [][]color.NRGBA) (int, int) {
+	bestIndex, bestChannel, bestRange := -1, 0, 0
+	for i, bucket := range buckets {
+		if len(bucket) < 2 {
+			continue
+		}
+		for channel := 0; channel < 3; channel++ {
+			low, high := 255, 0
+			for _, c := range bucket {
+				v := int(channelValue(c, channel))
+				low = min(low, v)
+				high = max(high, v)
+			}
+			if high-low > bestRange {
+				bestIndex, bestChannel, bestRange = i, channel, high-low
+			}
+		}
+	}
+	return bestIndex, bestChannel
+}
+
+func channelValue(c color.NRGBA, channel int) uint8 {
+	switch channel {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	default:
+		return c.B
+	}
+}
+
+func averageColor(bucket []color.NRGBA) color.Color {
+	var r, g, b int
+	for _, c := range bucket {
+		r += int(c.R)
+		g += int(c.G)
+		b += int(c.B)
+	}
+	n := len(bucket)
+	return color.NRGBA{uint8(r / n), uint8(g / n), uint8(b / n), 255}
+}
+
+// EncodeGIF writes frames as looping animated GIF. Frames are quantized to a
+// shared palette, optionally with Floyd-Steinberg dithering.
+func EncodeGIF(w io.Writer, frames []image.Image, fps int, dither bool) error {
+	writer := NewGIFWriter(w, BuildPalette(frames, paletteSize), fps, dither)
+	for _, frame := range frames {
+		if err := writer.AddFrame(frame); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// GIFWriter streams frames into a looping animated GIF with a shared global
+// palette. Each frame is quantized and written as it is added, so only one
+// frame is held in memory. All frames must share dimensions of first frame.
+type GIFWriter struct {
+	w       *bufio.Writer
+	palette color.Palette
+	delay   int
+	drawer  draw.Drawer
+	width   int
+	height  int
+	frames  int
+}
+
+func NewGIFWriter(w io.Writer, palette color.Palette, fps int, dither bool) *GIFWriter {
+	var drawer draw.Drawer = draw.Src
+	if dither {
+		drawer = draw.FloydSteinberg
+	}
+
+	return &GIFWriter{
+		w:       bufio.NewWriter(w),
+		palette: palette,
+		delay:   100 / max(1, fps),
+		drawer:  drawer,
+	}
+}
+
+// tableBits returns log2 of global color table size, covering the palette
+func (g *GIFWriter) tableBits() int {
+	bits := 1
+	for 1<<bits < len(g.palette) {
+		bits++
+	}
+	return bits
+}
+
+func (g *GIFWriter) writeHeader() error {
+	bits := g.tableBits()
+
+	header := []byte("GIF89a")
+	header = binary.LittleEndian.AppendUint16(header, uint16(g.width))
+	header = binary.LittleEndian.AppendUint16(header, uint16(g.height))
+	// Global color table flag, 8 bit color resolution and table size
+	header = append(header, 0x80|0x70|byte(bits-1), 0, 0)
+
+	for i := 0; i < 1<<bits; i++ {
+		var r, gr, b uint32
+		if i < len(g.palette) {
+			r, gr, b, _ = g.palette[i].RGBA()
+		}
+		header = append(header, byte(r>>8), byte(gr>>8), byte(b>>8))
+	}
+
+	// Netscape application extension, loop forever
+	header = append(header, 0x21, 0xff, 0x0b)
+	header = append(header, "NETSCAPE2.0"...)
+	header = append(header, 0x03, 0x01, 0x00, 0x00, 0x00)
+
+	_, err := g.w.Write(header)
+	return err
+}
+
+func (g *GIFWriter) AddFrame(img image.Image) error {
+	bounds := img.Bounds()
+	if g.frames == 0 {
+		if len(g.palette) == 0 || len(g.palette) > paletteSize {
+			return fmt.Errorf("palette must have between 1 and %d colors: %d", paletteSize, len(g.palette))
+		}
+		g.width = bounds.Dx()
+		g.height = bounds.Dy()
+		if err := g.writeHeader(); err != nil {
+			return err
+		}
+	} else if bounds.Dx() != g.width || bounds.Dy() != g.height {
+		return fmt.Errorf("frame size %dx%d differs from animation size %dx%d", bounds.Dx(), bounds.Dy(), g.width, g.height)
+	}
+
+	paletted := image.NewPaletted(image.Rect(0, 0, g.width, g.height), g.palette)
+	g.drawer.Draw(paletted, paletted.Bounds(), img, bounds.Min)
+
+	// Graphic control extension with frame delay, then image descriptor
+	frame := []byte{0x21, 0xf9, 0x04, 0x00}
+	frame = binary.LittleEndian.AppendUint16(frame, uint16(g.delay))
+	frame = append(frame, 0x00, 0x00, 0x2c, 0, 0, 0, 0)
+	frame = binary.LittleEndian.AppendUint16(frame, uint16(g.width))
+	frame = binary.LittleEndian.AppendUint16(frame, uint16(g.height))
+	litWidth := max(2, g.tableBits())
+	frame = append(frame, 0x00, byte(litWidth))
+	if _, err := g.w.Write(frame); err != nil {
+		return err
+	}
+
+	blocks := &blockWriter{w: g.w}
+	compressor := lzw.NewWriter(blocks, lzw.LSB, litWidth)
+	if _, err := compressor.Write(paletted.Pix); err != nil {
+		return fmt.Errorf("failed to encode frame: %w", err)
+	}
+	if err := compressor.Close(); err != nil {
+		return fmt.Errorf("failed to encode frame: %w", err)
+	}
+	if err := blocks.close(); err != nil {
+		return err
+	}
+
+	g.frames++
+	return nil
+}
+
+// Close writes the GIF trailer. It does not close underlying writer.
+func (g *GIFWriter) Close() error {
+	if g.frames == 0 {
+		return fmt.Errorf("no frames written")
+	}
+	if err := g.w.WriteByte(0x3b); err != nil {
+		return err
+	}
+	return g.w.Flush()
+}
+
+// blockWriter splits image data into GIF sub-blocks of at most 255 bytes
+type blockWriter struct {
+	w   *bufio.Writer
+	buf [255]byte
+	n   int
+}
+
+func (b *blockWriter) Write(p []byte) (int, error) {
+	for i, c := range p {
+		b.buf[b.n] = c
+		b.n++
+		if b.n == len(b.buf) {
+			if err := b.flush(); err != nil {
+				return i, err
+			}
+		}
+	}
+	return len(p), nil
+}
+
+func (b *blockWriter) flush() error {
+	if b.n == 0 {
+		return nil
+	}
+	if err := b.w.WriteByte(byte(b.n)); err != nil {
+		return err
+	}
+	_, err := b.w.Write(b.buf[:b.n])
+	b.n = 0
+	return err
+}
+
+// close writes remaining data and the block terminator
+func (b *blockWriter) close() error {
+	if err := b.flush(); err != nil {
+		return err
+	}
+	return b.w.WriteByte(0x00)
+}
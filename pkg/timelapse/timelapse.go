@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package timelapse
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/logging"
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+	"github.com/disintegration/imaging"
+)
+
+const (
+	FormatGIF = "gif"
+	FormatAVI = "avi"
+)
+
+var (
+	logger = logging.GetLogger()
+)
+
+type Config struct {
+	ArchiveDir      string
+	OutputPath      string
+	Format          string
+	From            time.Time
+	To              time.Time
+	MinInterval     time.Duration
+	Width           int
+	FPS             int
+	Quality         int
+	Dither          bool
+	Dedupe          bool
+	DedupeThreshold int
+}
+
+// Export selects archived frames and encodes them into a time-lapse video
+func Export(config Config) (int, error) {
+	format := config.Format
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(config.OutputPath)), ".")
+	}
+	if format != FormatGIF && format != FormatAVI {
+		return 0, fmt.Errorf("unsupported time-lapse format: %q", format)
+	}
+
+	if config.FPS < 1 {
+		return 0, fmt.Errorf("fps must be at least 1: %d", config.FPS)
+	}
+	if format == FormatAVI && (config.Quality < 1 || config.Quality > 100) {
+		return 0, fmt.Errorf("quality must be between 1 and 100: %d", config.Quality)
+	}
+
+	frames, err := SelectFrames(config.ArchiveDir, config.From, config.To)
+	if err != nil {
+		return 0, err
+	}
+	frames = ThinFrames(frames, config.MinInterval)
+	if len(frames) == 0 {
+		return 0, fmt.Errorf("no archived frames between %s and %s", config.From.Format(time.RFC3339), config.To.Format(time.RFC3339))
+	}
+	logger.WithField("frames", len(frames)).Info("Archived frames selected")
+
+	if err := os.MkdirAll(filepath.Dir(filepath.Clean(config.OutputPath)), 0750); err != nil {
+		return 0, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	switch format {
+	case FormatGIF:
+		return exportGIF(frames, config)
+	default:
+		return exportAVI(frames, config)
+	}
+}
+
+// exportGIF streams frames twice: first to sample the shared palette, then to
+// quantize and encode one frame at a time, so memory does not grow with the
+// number of frames
+func exportGIF(frames []Frame, config Config) (int, error) {
+	sampler := &PaletteSampler{}
+	err := eachFrame(frames, config, func(img image.Image) error {
+		sampler.Add(img)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	palette := sampler.Palette(paletteSize)
+
+	written := 0
+	err = util.WriteFileAtomic(config.OutputPath, func(w io.Writer) error {
+		writer := NewGIFWriter(w, palette, config.FPS, config.Dither)
+		err := eachFrame(frames, config, func(img image.Image) error {
+			written++
+			return writer.AddFrame(img)
+		})
+		if err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+func exportAVI(frames []Frame, config Config) (int, error) {
+	file, err := os.CreateTemp(filepath.Dir(filepath.Clean(config.OutputPath)), "."+filepath.Base(config.OutputPath)+".tmp-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create output file: %w", err)
+	}
+	tempPath := file.Name()
+	defer os.Remove(tempPath)
+	defer file.Close()
+
+	writer := NewMJPEGWriter(file, config.FPS, config.Quality)
+
+	written := 0
+	err = eachFrame(frames, config, func(img image.Image) error {
+		written++
+		return writer.AddFrame(img)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+
+	return written, os.Rename(tempPath, filepath.Clean(config.OutputPath))
+}
+
+// eachFrame decodes and downsamples frames in order, skipping frames that are
+// perceptually identical to the previous one when dedupe is enabled. Frames are
+// fitted to the size of the first frame, since videos need a fixed size.
+func eachFrame(frames []Frame, config Config, handle func(img image.Image) error) error {
+	kept := 0
+	var size image.Point
+	var previousHash uint64
+	for _, frame := range frames {
+		img, err := imaging.Open(frame.Path)
+		if err != nil {
+			logger.WithError(err).WithField("path", frame.Path).Warn("Skipping unreadable frame")
+			continue
+		}
+
+		if config.Width > 0 && img.Bounds().Dx() > config.Width {
+			img = imaging.Resize(img, config.Width, 0, imaging.Lanczos)
+		}
+
+		if kept == 0 {
+			size = img.Bounds().Size()
+		} else if img.Bounds().Size() != size {
+			img = imaging.Fill(img, size.X, size.Y, imaging.Center, imaging.Lanczos)
+		}
+
+		if config.Dedupe {
+			hash := postprocess.DifferenceHash(img)
+			if kept > 0 && postprocess.HammingDistance(hash, previousHash) <= config.DedupeThreshold {
+				continue
+			}
+			previousHash = hash
+		}
+
+		kept++
+		if err := handle(img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package timelapse
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeArchiveFrame(t *testing.T, dir string, frameTime time.Time, reversed bool) {
+	t.Helper()
+	img := gradient(64, 48)
+	if reversed {
+		img = imaging.FlipH(img)
+	}
+	path := filepath.Join(dir, "latest_"+frameTime.Format(ArchiveTimeLayout)+".jpg")
+	require.NoError(t, imaging.Save(img, path), "Failed to write archive frame")
+}
+
+func TestSelectFrames(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+
+	writeArchiveFrame(t, dir, base.Add(2*time.Hour), false)
+	writeArchiveFrame(t, dir, base, false)
+	writeArchiveFrame(t, dir, base.Add(24*time.Hour), true)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0600), "Failed to write unrelated file")
+
+	frames, err := SelectFrames(dir, base, base.Add(3*time.Hour))
+	require.NoError(t, err, "SelectFrames should succeed")
+	require.Len(t, frames, 2, "Only frames within range should be selected")
+	assert.Equal(t, base, frames[0].Time, "Frames should be sorted by time")
+	assert.Equal(t, base.Add(2*time.Hour), frames[1].Time, "Frames should be sorted by time")
+}
+
+func TestThinFrames(t *testing.T) {
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	var frames []Frame
+	for i := 0; i < 6; i++ {
+		frames = append(frames, Frame{Time: base.Add(time.Duration(i*10) * time.Minute)})
+	}
+
+	thinned := ThinFrames(frames, 25*time.Minute)
+	require.Len(t, thinned, 2, "Frames closer than the interval should be dropped")
+	assert.Equal(t, base.Add(30*time.Minute), thinned[1].Time, "Second frame should be first one past the interval")
+	assert.Len(t, ThinFrames(frames, 0), 6, "Zero interval should keep all frames")
+}
+
+func TestEncodeGIF(t *testing.T) {
+	frames := []image.Image{
+		imaging.New(32, 24, color.NRGBA{255, 0, 0, 255}),
+		imaging.New(32, 24, color.NRGBA{0, 0, 255, 255}),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeGIF(&buf, frames, 10, true), "EncodeGIF should succeed")
+
+	decoded, err := gif.DecodeAll(&buf)
+	require.NoError(t, err, "Encoded GIF should decode")
+	assert.Len(t, decoded.Image, 2, "GIF should contain all frames")
+	assert.Equal(t, 10, decoded.Delay[0], "Delay should be derived from fps")
+}
+
+func TestBuildPalette(t *testing.T) {
+	frames := []image.Image{gradient(64, 16)}
+	palette := BuildPalette(frames, 16)
+	assert.Len(t, palette, 16, "Palette should be split to requested size")
+}
+
+func gradient(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(x * 255 / width)
+			img.SetNRGBA(x, y, color.NRGBA{v, 255 - v, v / 2, 255})
+		}
+	}
+	return img
+}
+
+func TestExportAVI(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	for i := 0; i < 3; i++ {
+		writeArchiveFrame(t, dir, base.Add(time.Duration(i)*time.Hour), i%2 == 1)
+	}
+
+	output := filepath.Join(dir, "out", "timelapse.avi")
+	count, err := Export(Config{
+		ArchiveDir: dir,
+		OutputPath: output,
+		From:       base,
+		To:         base.Add(24 * time.Hour),
+		FPS:        12,
+		Quality:    80,
+	})
+	require.NoError(t, err, "Export should succeed")
+	assert.Equal(t, 3, count, "All frames should be written")
+
+	data, err := os.ReadFile(output)
+	require.NoError(t, err, "Output should exist")
+	assert.Equal(t, "RIFF", string(data[0:4]), "Output should be a RIFF file")
+	assert.Equal(t, "AVI ", string(data[8:12]), "Output should be an AVI file")
+	assert.Equal(t, uint32(len(data)-8), binary.LittleEndian.Uint32(data[4:8]), "RIFF size should be patched")
+	assert.Equal(t, uint32(3), binary.LittleEndian.Uint32(data[48:52]), "Frame count should be patched")
+}
+
+func TestExportDedupe(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	writeArchiveFrame(t, dir, base, false)
+	writeArchiveFrame(t, dir, base.Add(time.Hour), false)
+	writeArchiveFrame(t, dir, base.Add(2*time.Hour), true)
+
+	count, err := Export(Config{
+		ArchiveDir:      dir,
+		OutputPath:      filepath.Join(dir, "timelapse.gif"),
+		From:            base,
+		To:              base.Add(24 * time.Hour),
+		FPS:             5,
+		Dedupe:          true,
+		DedupeThreshold: 2,
+	})
+	require.NoError(t, err, "Export should succeed")
+	assert.Equal(t, 2, count, "Identical consecutive frames should be dropped")
+}
+
+func TestExportGIF(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 3, 1, 12, 0, 0, 0, time.Local)
+	for i := 0; i < 3; i++ {
+		writeArchiveFrame(t, dir, base.Add(time.Duration(i)*time.Hour), i%2 == 1)
+	}
+
+	output := filepath.Join(dir, "timelapse.gif")
+	count, err := Export(Config{
+		ArchiveDir: dir,
+		OutputPath: output,
+		From:       base,
+		To:         base.Add(24 * time.Hour),
+		FPS:        4,
+		Dither:     true,
+	})
+	require.NoError(t, err, "Export should succeed")
+	assert.Equal(t, 3, count, "All frames should be written")
+
+	file, err := os.Open(output)
+	require.NoError(t, err, "Output should exist")
+	defer file.Close()
+	decoded, err := gif.DecodeAll(file)
+	require.NoError(t, err, "Streamed GIF should decode")
+	assert.Len(t, decoded.Image, 3, "GIF should contain all frames")
+	assert.Equal(t, 25, decoded.Delay[2], "Delay should be derived from fps")
+	assert.Equal(t, 0, decoded.LoopCount, "GIF should loop forever")
+	assert.Equal(t, image.Rect(0, 0, 64, 48), decoded.Image[1].Bounds(), "Frames should keep their size")
+}
+
+func TestGIFWriterRejectsSizeChange(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewGIFWriter(&buf, color.Palette{color.Black, color.White}, 10, false)
+	require.NoError(t, writer.AddFrame(imaging.New(8, 8, color.White)), "First frame should be written")
+	assert.Error(t, writer.AddFrame(imaging.New(4, 4, color.White)), "Frames of different size should be rejected")
+	assert.Error(t, NewGIFWriter(&buf, nil, 10, false).Close(), "Closing without frames should fail")
+}
+
+func TestExportQuality(t *testing.T) {
+	for _, quality := range []int{0, -5, 101} {
+		_, err := Export(Config{OutputPath: "timelapse.avi", FPS: 10, Quality: quality})
+		assert.ErrorContains(t, err, "quality", "Quality %d should be rejected", quality)
+	}
+}
+
+func TestExportUnsupportedFormat(t *testing.T) {
+	_, err := Export(Config{OutputPath: "timelapse.mp4", FPS: 10})
+	assert.Error(t, err, "Unknown formats should be rejected")
+}
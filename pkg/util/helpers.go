@@ -75,6 +75,10 @@ func DownloadImage(url, path string, honest bool) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download image: status code %d", resp.StatusCode)
+	}
+
 	file, err := os.Create(filepath.Clean(path))
 	if err != nil {
 		return fmt.Errorf("failed to create file for downloaded image: %w", err)
@@ -147,22 +151,59 @@ func CopyFile(src, dst string) error {
 	}
 	defer sourceFile.Close()
 
-	destFile, err := os.Create(dst)
-	if err != nil {
+	return WriteFileAtomic(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, sourceFile)
 		return err
-	}
-	defer destFile.Close()
+	})
+}
 
-	_, err = io.Copy(destFile, sourceFile)
+// defaultFileMode matches files created by os.Create under the common 022 umask
+const defaultFileMode os.FileMode = 0644
+
+// WriteFileAtomic writes to a temporary file in the destination directory and
+// renames it into place, so readers never see a partially written file. The
+// file keeps the mode of the file it replaces, new files are world readable.
+func WriteFileAtomic(path string, write func(w io.Writer) error) error {
+	cleanPath := filepath.Clean(path)
+	tempFile, err := os.CreateTemp(filepath.Dir(cleanPath), "."+filepath.Base(cleanPath)+".tmp-*")
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to create temporary file: %w", err)
 	}
+	tempPath := tempFile.Name()
 
-	err = destFile.Sync()
-	if err != nil {
+	success := false
+	defer func() {
+		if !success {
+			tempFile.Close()
+			os.Remove(tempPath)
+		}
+	}()
+
+	if err := write(tempFile); err != nil {
 		return err
 	}
 
+	if err := tempFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	mode := defaultFileMode
+	if info, err := os.Stat(cleanPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(tempPath, mode); err != nil {
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+
+	if err := os.Rename(tempPath, cleanPath); err != nil {
+		return fmt.Errorf("failed to move file into place: %w", err)
+	}
+
+	success = true
 	return nil
 }
 
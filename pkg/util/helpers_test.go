@@ -7,11 +7,14 @@ package util
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"image"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,6 +42,19 @@ func TestDownloadImage(t *testing.T) {
 	assert.FileExists(t, testFilePath, "The downloaded file should exist")
 }
 
+func TestDownloadImageStatusError(t *testing.T) {
+	server := http.FileServer(http.Dir("./testdata"))
+	testServer := httptest.NewServer(server)
+	defer testServer.Close()
+
+	testFilePath := "./test_missing.jpg"
+	defer os.Remove(testFilePath)
+
+	err := DownloadImage(testServer.URL+"/missing.jpg", testFilePath, true)
+	assert.Error(t, err, "downloadImage should fail on non-OK status codes")
+	assert.NoFileExists(t, testFilePath, "No file should be written for failed downloads")
+}
+
 func TestHashSHA256(t *testing.T) {
 	data := "test data"
 	expectedHash := sha256.Sum256([]byte(data))
@@ -79,6 +95,65 @@ func TestCopyFile(t *testing.T) {
 	assert.Equal(t, srcContent, dstContent, "Source and destination file contents should match")
 }
 
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "output.txt")
+
+	err := WriteFileAtomic(path, func(w io.Writer) error {
+		_, err := w.Write([]byte("complete"))
+		return err
+	})
+	require.NoError(t, err, "WriteFileAtomic should not return an error")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err, "os.ReadFile should not return an error")
+	assert.Equal(t, "complete", string(content), "File should contain written data")
+
+	err = WriteFileAtomic(path, func(w io.Writer) error {
+		_, _ = w.Write([]byte("partial"))
+		return errors.New("write interrupted")
+	})
+	assert.Error(t, err, "WriteFileAtomic should return write errors")
+
+	content, err = os.ReadFile(path)
+	require.NoError(t, err, "os.ReadFile should not return an error")
+	assert.Equal(t, "complete", string(content), "Failed write should leave previous file untouched")
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err, "os.ReadDir should not return an error")
+	assert.Len(t, entries, 1, "Failed write should not leave temporary files behind")
+}
+
+func TestWriteFileAtomicMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	write := func(w io.Writer) error {
+		_, err := w.Write([]byte("data"))
+		return err
+	}
+
+	path := filepath.Join(dir, "latest.jpg")
+	require.NoError(t, WriteFileAtomic(path, write), "WriteFileAtomic should not return an error")
+	info, err := os.Stat(path)
+	require.NoError(t, err, "os.Stat should not return an error")
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm(), "New files should be readable by other users")
+
+	require.NoError(t, os.Chmod(path, 0640), "os.Chmod should not return an error")
+	require.NoError(t, WriteFileAtomic(path, write), "WriteFileAtomic should not return an error")
+	info, err = os.Stat(path)
+	require.NoError(t, err, "os.Stat should not return an error")
+	assert.Equal(t, os.FileMode(0640), info.Mode().Perm(), "Replaced files should keep their mode")
+
+	copyPath := filepath.Join(dir, "copy.jpg")
+	require.NoError(t, CopyFile(path, copyPath), "CopyFile should not return an error")
+	info, err = os.Stat(copyPath)
+	require.NoError(t, err, "os.Stat should not return an error")
+	assert.Equal(t, os.FileMode(0644), info.Mode().Perm(), "Copies should be readable by other users")
+}
+
 func TestFileExists(t *testing.T) {
 	existingFile := "./testdata/sample.txt"
 	nonExistingFile := "./testdata/non_existent.txt"
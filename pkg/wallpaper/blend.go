@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/TilmanGriesel/AlpineZen/pkg/repository"
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+)
+
+const (
+	BlendModeNone   = "none"
+	BlendModeEMA    = "ema"
+	BlendModeMean   = postprocess.StackMean
+	BlendModeMedian = postprocess.StackMedian
+	BlendModeMax    = postprocess.StackMax
+
+	DefaultBlendAlpha  = 0.5
+	DefaultBlendFrames = 5
+	maxBlendFrames     = 32
+	stackDirName       = "stack"
+)
+
+// BlendConfig smooths consecutive frames over time. Either an exponential
+// moving average with the previous output or a stack of the last frames.
+// For compatibility a plain boolean enables an even blend with the previous frame.
+type BlendConfig struct {
+	Mode   string  `yaml:"mode"`
+	Alpha  float64 `yaml:"alpha"`
+	Frames int     `yaml:"frames"`
+}
+
+func (b *BlendConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var enabled bool
+	if err := unmarshal(&enabled); err == nil {
+		*b = BlendConfig{Mode: BlendModeNone}
+		if enabled {
+			b.Mode = BlendModeEMA
+		}
+		return nil
+	}
+
+	type plain BlendConfig
+	return unmarshal((*plain)(b))
+}
+
+func (b BlendConfig) enabled() bool {
+	return b.Mode != "" && b.Mode != BlendModeNone
+}
+
+func (b BlendConfig) stacking() bool {
+	return b.Mode == BlendModeMean || b.Mode == BlendModeMedian || b.Mode == BlendModeMax
+}
+
+func (b BlendConfig) alpha() float64 {
+	if b.Alpha <= 0 {
+		return DefaultBlendAlpha
+	}
+	return b.Alpha
+}
+
+func (b BlendConfig) frames() int {
+	if b.Frames <= 0 {
+		return DefaultBlendFrames
+	}
+	return b.Frames
+}
+
+func (wm *WallpaperManager) validateBlend() error {
+	blend := wm.WallpaperManagerConfig.Output.Blend
+	switch blend.Mode {
+	case "", BlendModeNone, BlendModeEMA, BlendModeMean, BlendModeMedian, BlendModeMax:
+	default:
+		return fmt.Errorf("unknown blend mode: %q", blend.Mode)
+	}
+
+	if blend.Alpha < 0 || blend.Alpha > 1 {
+		return fmt.Errorf("blend alpha must be between 0 and 1: %v", blend.Alpha)
+	}
+	if blend.Frames > maxBlendFrames {
+		return fmt.Errorf("blend frames must not exceed %d: %d", maxBlendFrames, blend.Frames)
+	}
+	return nil
+}
+
+func (wm *WallpaperManager) stackDirPath() (string, error) {
+	appDirPath, err := util.GetAppDirPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDirPath, "files", util.GenerateShortHash(wm.primaryURL(), ""), stackDirName), nil
+}
+
+// applyBlend smooths a freshly processed frame with earlier frames
+func (wm *WallpaperManager) applyBlend(img image.Image, previousProcImageFilePath string) image.Image {
+	blend := wm.WallpaperManagerConfig.Output.Blend
+	if !blend.enabled() {
+		return img
+	}
+
+	if blend.stacking() {
+		stacked, err := wm.stackFrames(img, blend)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to stack frames")
+			return img
+		}
+		return stacked
+	}
+
+	if !util.FileExists(previousProcImageFilePath) {
+		return img
+	}
+
+	previousImage, err := wm.currentFrame(previousProcImageFilePath)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to load previous processed image")
+		return img
+	}
+	if previousImage.Bounds().Size() != img.Bounds().Size() {
+		return img
+	}
+
+	// Cache holds the previous blended output, so overlaying it with 1-alpha yields the moving average
+	return wm.overlay(img, previousImage, image.Pt(0, 0), 1-blend.alpha())
+}
+
+// stackFrames adds frame to the stack directory, prunes it to the configured
+// number of frames and combines all frames of matching size
+func (wm *WallpaperManager) stackFrames(img image.Image, blend BlendConfig) (image.Image, error) {
+	stackDir, err := wm.stackDirPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(stackDir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create stack directory: %w", err)
+	}
+
+	framePath := filepath.Join(stackDir, strconv.FormatInt(time.Now().UnixNano(), 10)+FileType)
+	if err := saveImage(img, framePath); err != nil {
+		return nil, fmt.Errorf("failed to save stack frame: %w", err)
+	}
+
+	janitor := repository.NewJanitor(FileType)
+	if err := janitor.WipeThrough(stackDir, blend.frames()); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(stackDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stack directory: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	frames := []image.Image{img}
+	for _, entry := range entries {
+		if entry.Name() == filepath.Base(framePath) || filepath.Ext(entry.Name()) != FileType {
+			continue
+		}
+
+		frame, err := util.LoadImageFile(filepath.Join(stackDir, entry.Name()))
+		if err != nil {
+			logger.WithError(err).WithField("frame", entry.Name()).Warn("Skipping unreadable stack frame")
+			continue
+		}
+		// Frames rendered at another size, e.g. before a resolution change, are ignored
+		if frame.Bounds().Size() != img.Bounds().Size() {
+			continue
+		}
+		frames = append(frames, frame)
+	}
+
+	logger.WithField("frames", len(frames)).WithField("mode", blend.Mode).Debug("Stacking frames")
+	return postprocess.Stack(frames, blend.Mode)
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/TilmanGriesel/AlpineZen/pkg/sanitizer"
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+	"github.com/disintegration/imaging"
+)
+
+// validateColorSpace checks the output color space tag
+func (wm *WallpaperManager) validateColorSpace() error {
+	switch wm.WallpaperManagerConfig.Output.ColorSpace {
+	case "", postprocess.ColorSpaceSRGB, postprocess.ColorSpaceDisplayP3:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output color space: %q", wm.WallpaperManagerConfig.Output.ColorSpace)
+	}
+}
+
+func (wm *WallpaperManager) linearLight() bool {
+	return wm.WallpaperManagerConfig.ImageProcessing.LinearLight
+}
+
+// sanitizeFrame sanitizes a downloaded image and remembers the color space
+// named by the metadata sanitizing strips
+func (wm *WallpaperManager) sanitizeFrame(path string) error {
+	info, err := sanitizer.SanitizeImageInfo(path)
+	if err != nil {
+		return err
+	}
+
+	if wm.colorSpaceHints == nil {
+		wm.colorSpaceHints = make(map[string]string)
+	}
+	if info.ColorSpace == "" || info.ColorSpace == postprocess.ColorSpaceSRGB {
+		delete(wm.colorSpaceHints, path)
+		return nil
+	}
+
+	logger.WithField("path", path).WithField("colorSpace", info.ColorSpace).Debug("Image is not sRGB, converting on load")
+	wm.colorSpaceHints[path] = info.ColorSpace
+	return nil
+}
+
+// openFrame opens a sanitized image and converts it to sRGB when its
+// metadata named another color space
+func (wm *WallpaperManager) openFrame(path string) (image.Image, error) {
+	img, err := imaging.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	colorSpace, ok := wm.colorSpaceHints[path]
+	if !ok {
+		return img, nil
+	}
+
+	converted, err := postprocess.ConvertColorSpace(img, colorSpace, postprocess.ColorSpaceSRGB)
+	if err != nil {
+		return nil, err
+	}
+	if wm.highPrecision() {
+		return converted, nil
+	}
+	return wm.quantize(converted), nil
+}
+
+// overlay draws img over background, in linear light when enabled
+func (wm *WallpaperManager) overlay(background, img image.Image, pos image.Point, opacity float64) image.Image {
+	if wm.linearLight() {
+		return wm.quantize(postprocess.OverlayLinear(background, img, pos, opacity))
+	}
+	return imaging.Overlay(background, img, pos, opacity)
+}
+
+// toOutputColorSpace converts the sRGB working image to the output color space
+func (wm *WallpaperManager) toOutputColorSpace(img image.Image) image.Image {
+	colorSpace := wm.WallpaperManagerConfig.Output.ColorSpace
+	if colorSpace == "" || colorSpace == postprocess.ColorSpaceSRGB {
+		return img
+	}
+
+	converted, err := postprocess.ConvertColorSpace(img, postprocess.ColorSpaceSRGB, colorSpace)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to convert output color space")
+		return img
+	}
+	return wm.quantize(converted)
+}
+
+// encodeTagged encodes img and embeds the output color space profile. Pixels
+// must already be in the output color space.
+func (wm *WallpaperManager) encodeTagged(w io.Writer, img image.Image, format imaging.Format, opts ...imaging.EncodeOption) error {
+	colorSpace := wm.WallpaperManagerConfig.Output.ColorSpace
+	if colorSpace == "" {
+		return imaging.Encode(w, img, format, opts...)
+	}
+
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, img, format, opts...); err != nil {
+		return err
+	}
+
+	profile, err := postprocess.ICCProfile(colorSpace)
+	if err != nil {
+		return err
+	}
+	data, err := postprocess.EmbedICCProfile(buf.Bytes(), format, profile, colorSpace)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// saveTaggedImage saves an image shown to the user with the output color space profile
+func (wm *WallpaperManager) saveTaggedImage(img image.Image, path string, opts ...imaging.EncodeOption) error {
+	format, err := imaging.FormatFromFilename(path)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteFileAtomic(path, func(w io.Writer) error {
+		return wm.encodeTagged(w, img, format, opts...)
+	})
+}
@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess/composite"
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StepComposite = "composite"
+)
+
+// CompositeConfig combines several webcam inputs into a single wallpaper
+type CompositeConfig struct {
+	Layout      string          `yaml:"layout"`
+	Columns     int             `yaml:"columns"`
+	Gap         int             `yaml:"gap"`
+	InsetScale  float64         `yaml:"inset_scale"`
+	InsetMargin int             `yaml:"inset_margin"`
+	Feather     float64         `yaml:"feather"`
+	Tiles       []CompositeTile `yaml:"tiles"`
+}
+
+// CompositeTile is one composite input. Tiles without their own
+// image_processing section use the profile wide enhancement settings.
+type CompositeTile struct {
+	InputSource     `yaml:",inline"`
+	ImageProcessing *EnhancementConfig `yaml:"image_processing"`
+}
+
+func (wm *WallpaperManager) compositeEnabled() bool {
+	return len(wm.WallpaperManagerConfig.Composite.Tiles) > 0
+}
+
+func (wm *WallpaperManager) validateComposite() error {
+	if !wm.compositeEnabled() {
+		return nil
+	}
+
+	switch wm.WallpaperManagerConfig.Composite.Layout {
+	case composite.LayoutGrid, composite.LayoutPIP, composite.LayoutSideBySide:
+	default:
+		return fmt.Errorf("unknown composite layout: %q", wm.WallpaperManagerConfig.Composite.Layout)
+	}
+
+	if len(wm.WallpaperManagerConfig.Pipeline) == 0 {
+		return nil
+	}
+	for _, step := range wm.WallpaperManagerConfig.Pipeline {
+		if step.Step == StepComposite {
+			return nil
+		}
+	}
+	return fmt.Errorf("pipeline must contain a %q step when composite tiles are configured", StepComposite)
+}
+
+// fetchTiles downloads, sanitizes and decodes every composite tile. Tiles that
+// fail are left nil so layout keeps its place empty.
+func (wm *WallpaperManager) fetchTiles(tempImagePath string) ([]image.Image, error) {
+	tiles := wm.WallpaperManagerConfig.Composite.Tiles
+	images := make([]image.Image, len(tiles))
+
+	fetched := 0
+	for i, tile := range tiles {
+		tileLogger := logger.WithFields(logrus.Fields{
+			"tile": i,
+			"url":  tile.URL,
+		})
+		tilePath := filepath.Join(tempImagePath, fmt.Sprintf("tile_%d", i))
+
+		tileLogger.Debug("Fetching composite tile")
+		if err := util.DownloadImage(tile.URL, tilePath, false); err != nil {
+			tileLogger.WithError(err).Warn("Failed to download composite tile")
+			continue
+		}
+
+		if err := wm.sanitizeFrame(tilePath); err != nil {
+			tileLogger.WithError(err).Warn("Failed to sanitize composite tile")
+			continue
+		}
+
+		if err := wm.validateFrame(tilePath); err != nil {
+			tileLogger.WithField("reason", err.Error()).Warn("Rejected composite tile")
+			continue
+		}
+
+		img, err := wm.openFrame(tilePath)
+		if err != nil {
+			tileLogger.WithError(err).Warn("Failed to open composite tile")
+			continue
+		}
+
+		images[i] = img
+		fetched++
+	}
+
+	if fetched == 0 {
+		return nil, fmt.Errorf("all %d composite tiles failed", len(tiles))
+	}
+
+	return images, nil
+}
+
+// prepareTiles applies each tile's own crop and enhancement settings
+func (wm *WallpaperManager) prepareTiles(images []image.Image) []image.Image {
+	tiles := wm.WallpaperManagerConfig.Composite.Tiles
+	prepared := make([]image.Image, len(images))
+
+	for i, img := range images {
+		if img == nil {
+			continue
+		}
+
+		enhancement := wm.WallpaperManagerConfig.ImageProcessing.EnhancementConfig
+		if tiles[i].ImageProcessing != nil {
+			enhancement = *tiles[i].ImageProcessing
+		}
+
+		img = wm.cropImage(img, tiles[i].CropFactor, tiles[i].OffsetX, tiles[i].OffsetY, tiles[i].CropMode, tiles[i].Anchor)
+		prepared[i] = wm.quantize(wm.enhanceImage(img, enhancement))
+	}
+
+	return prepared
+}
+
+func (wm *WallpaperManager) composeTiles(images []image.Image) image.Image {
+	config := wm.WallpaperManagerConfig.Composite
+	width := wm.WallpaperConfig.TargetDimensions.Width
+	height := wm.WallpaperConfig.TargetDimensions.Height
+
+	tiles := wm.prepareTiles(images)
+
+	switch config.Layout {
+	case composite.LayoutPIP:
+		return composite.PictureInPicture(width, height, tiles, config.InsetScale, config.InsetMargin, wm.resampleFilter)
+	case composite.LayoutSideBySide:
+		return composite.SideBySide(width, height, tiles, config.Feather, wm.resampleFilter)
+	default:
+		return composite.Grid(width, height, tiles, config.Columns, config.Gap, wm.resampleFilter)
+	}
+}
+
+func (wm *WallpaperManager) processComposite(images []image.Image) (image.Image, error) {
+	logger.WithField("tiles", len(images)).Debug("Processing composite")
+
+	var main image.Image
+	for _, img := range images {
+		if img != nil {
+			main = img
+			break
+		}
+	}
+
+	finalImage, err := wm.runPipeline(&pipelineContext{original: main, tiles: images})
+	if err != nil {
+		logger.WithError(err).Error("Failed to run processing pipeline")
+		return nil, err
+	}
+
+	return finalImage, nil
+}
@@ -0,0 +1,116 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"image"
+	"image/draw"
+	"time"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess/render"
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+	"github.com/disintegration/imaging"
+)
+
+// transitionStatsStep subsamples intermediate frames when scaling clock
+// opacity, each frame is shown only briefly
+const transitionStatsStep = 4
+
+// frameState keeps the processed frame in memory between updates. Clock
+// updates draw onto it instead of reloading the cache file, and only the
+// clock region is rendered again.
+type frameState struct {
+	// path is the cache file holding frame
+	path  string
+	frame image.Image
+
+	// base is frame with the stale badge, in sRGB; output is base in the
+	// output color space. Both are derived on first render.
+	base   image.Image
+	output *image.NRGBA
+	stats  *postprocess.ImageStats
+
+	clock render.ClockRenderer
+}
+
+// setFrame makes img, saved at path, the current processed frame
+func (wm *WallpaperManager) setFrame(path string, img image.Image) {
+	wm.frameState = frameState{path: path, frame: img, clock: wm.frameState.clock}
+}
+
+// resetFrame drops the in-memory frame, for when its cache file is removed
+func (wm *WallpaperManager) resetFrame() {
+	wm.setFrame("", nil)
+}
+
+// currentFrame returns the processed frame saved at path, loading it from
+// disk only when it is not already in memory
+func (wm *WallpaperManager) currentFrame(path string) (image.Image, error) {
+	if wm.frameState.frame != nil && wm.frameState.path == path {
+		return wm.frameState.frame, nil
+	}
+
+	img, err := util.LoadImageFile(path)
+	if err != nil {
+		return nil, err
+	}
+	wm.setFrame(path, img)
+	return img, nil
+}
+
+// renderFrame returns the current frame as shown to the user, with stale
+// badge, clock and output color space applied
+func (wm *WallpaperManager) renderFrame() image.Image {
+	state := &wm.frameState
+	if state.base == nil {
+		state.base = wm.applyStaleBadge(state.frame)
+		state.output = imaging.Clone(wm.toOutputColorSpace(state.base))
+		state.stats = postprocess.MeasureImage(state.base, postprocess.MeasureOptions{})
+	}
+
+	if wm.WallpaperConfig.DisableClock {
+		return state.output
+	}
+
+	layout, err := state.clock.Layout(state.base.Bounds(), wm.WallpaperConfig.FontConfigClock, time.Now())
+	if err != nil {
+		logger.WithError(err).Warn("Failed to lay out clock")
+		return state.output
+	}
+	if layout.Region.Empty() {
+		return state.output
+	}
+
+	// Dither patterns of the color space conversion stay aligned with the
+	// rest of the frame when the region starts on a tile boundary
+	bounds := state.base.Bounds()
+	region := layout.Region
+	region.Min.X -= (region.Min.X - bounds.Min.X) % postprocess.DitherTile
+	region.Min.Y -= (region.Min.Y - bounds.Min.Y) % postprocess.DitherTile
+
+	patch := wm.toOutputColorSpace(state.clock.DrawRegion(state.base, layout, region, state.stats))
+
+	frame := imaging.Clone(state.output)
+	draw.Draw(frame, region.Sub(bounds.Min), patch, image.Point{}, draw.Src)
+	return frame
+}
+
+// renderTransitionFrame applies stale badge, clock and output color space to
+// an intermediate transition frame, in the same order as renderFrame
+func (wm *WallpaperManager) renderTransitionFrame(img image.Image) (image.Image, error) {
+	img = wm.applyStaleBadge(img)
+
+	if !wm.WallpaperConfig.DisableClock {
+		stats := postprocess.MeasureImage(img, postprocess.MeasureOptions{Step: transitionStatsStep})
+		clockFrame, err := wm.frameState.clock.Draw(img, wm.WallpaperConfig.FontConfigClock, stats)
+		if err != nil {
+			return nil, err
+		}
+		img = clockFrame
+	}
+
+	return wm.toOutputColorSpace(img), nil
+}
@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTransitionFrameMatchesFinalFrame(t *testing.T) {
+	img := imaging.New(64, 48, color.NRGBA{200, 40, 30, 255})
+
+	for _, colorSpace := range []string{"", postprocess.ColorSpaceDisplayP3} {
+		wm := &WallpaperManager{}
+		wm.WallpaperConfig.DisableClock = true
+		wm.WallpaperManagerConfig.Output.ColorSpace = colorSpace
+		wm.sourceStale = true
+		wm.WallpaperManagerConfig.Input.StaleDetection.Action = StaleActionBadge
+
+		wm.setFrame("cache.png", img)
+		final := imaging.Clone(wm.renderFrame())
+
+		transition, err := wm.renderTransitionFrame(img)
+		require.NoError(t, err, "Rendering a transition frame should succeed")
+		assert.Equal(t, final.Pix, imaging.Clone(transition).Pix, "Transition frames should be rendered like the final frame in color space %q", colorSpace)
+	}
+}
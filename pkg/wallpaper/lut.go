@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess/adjustment"
+)
+
+// loadLUT loads a .cube file from the profile directory once and caches it
+func (wm *WallpaperManager) loadLUT(name string) (*adjustment.LUT3D, error) {
+	if lut, ok := wm.luts[name]; ok {
+		return lut, nil
+	}
+
+	if !filepath.IsLocal(name) {
+		return nil, fmt.Errorf("LUT must be located in the profile directory: %q", name)
+	}
+
+	lut, err := adjustment.LoadCube(filepath.Join(filepath.Dir(wm.configPath), name))
+	if err != nil {
+		return nil, err
+	}
+
+	if wm.luts == nil {
+		wm.luts = make(map[string]*adjustment.LUT3D)
+	}
+	wm.luts[name] = lut
+	logger.WithField("lut", name).WithField("size", lut.Size).Debug("LUT loaded")
+	return lut, nil
+}
+
+// validateLUTs loads all LUTs referenced by the profile, so broken files fail on load
+func (wm *WallpaperManager) validateLUTs() error {
+	wm.luts = nil
+
+	for _, config := range wm.enhancementConfigs() {
+		if config.LUT == "" {
+			continue
+		}
+		if _, err := wm.loadLUT(config.LUT); err != nil {
+			return err
+		}
+		if strength := floatOrDefault(config.LUTStrength, 1); strength < 0 || strength > 1 {
+			return fmt.Errorf("lut_strength must be between 0 and 1: %v", strength)
+		}
+	}
+	return nil
+}
+
+func (wm *WallpaperManager) applyLUT(img image.Image, config EnhancementConfig) image.Image {
+	if config.LUT == "" {
+		return img
+	}
+
+	lut, err := wm.loadLUT(config.LUT)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to load LUT")
+		return img
+	}
+
+	if _, ok := img.(*image.NRGBA64); ok {
+		return lut.Apply64(img, floatOrDefault(config.LUTStrength, 1))
+	}
+	return lut.Apply(img, floatOrDefault(config.LUTStrength, 1))
+}
@@ -0,0 +1,287 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/TilmanGriesel/AlpineZen/pkg/repository"
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+	"github.com/disintegration/imaging"
+)
+
+const (
+	DefaultJPEGQuality    = 95
+	defaultOutputFileName = "wallpaper.png"
+)
+
+var pngCompressionLevels = map[string]png.CompressionLevel{
+	"":        png.DefaultCompression,
+	"default": png.DefaultCompression,
+	"none":    png.NoCompression,
+	"fast":    png.BestSpeed,
+	"best":    png.BestCompression,
+}
+
+// ExportRetention prunes exported files in the directory of the current
+// export path. Every limit is disabled when zero.
+type ExportRetention struct {
+	MaxFiles  int           `yaml:"max_files"`
+	MaxAge    time.Duration `yaml:"max_age"`
+	MaxSizeMB int64         `yaml:"max_size_mb"`
+}
+
+func (r ExportRetention) policy() repository.RetentionPolicy {
+	return repository.RetentionPolicy{
+		MaxFiles: r.MaxFiles,
+		MaxAge:   r.MaxAge,
+		MaxBytes: r.MaxSizeMB * 1024 * 1024,
+	}
+}
+
+// outputTemplateData holds placeholders available in export_path templates
+type outputTemplateData struct {
+	Profile   string
+	Type      string
+	Date      string
+	Time      string
+	Timestamp int64
+	Width     int
+	Height    int
+	Now       time.Time
+}
+
+// prepareOutput validates output settings and resolves them into values used during processing
+func (wm *WallpaperManager) prepareOutput() error {
+	output := wm.WallpaperManagerConfig.Output
+
+	switch output.FitMode {
+	case "", postprocess.FitCover, postprocess.FitContain, postprocess.FitContainBlur, postprocess.FitStretch:
+	default:
+		return fmt.Errorf("unknown fit mode: %q", output.FitMode)
+	}
+
+	filter, err := postprocess.ParseResampleFilter(output.ResampleFilter)
+	if err != nil {
+		return err
+	}
+	wm.resampleFilter = filter
+
+	if _, ok := pngCompressionLevels[output.PNGCompression]; !ok {
+		return fmt.Errorf("unknown png compression: %q", output.PNGCompression)
+	}
+
+	if output.JPEGQuality < 0 || output.JPEGQuality > 100 {
+		return fmt.Errorf("jpeg quality must be between 1 and 100, or 0 for the default: %d", output.JPEGQuality)
+	}
+
+	if err := postprocess.ValidateDither(output.Dither); err != nil {
+		return err
+	}
+
+	if err := wm.validateColorSpace(); err != nil {
+		return err
+	}
+
+	if output.ExportPath != "" {
+		if _, err := template.New("export_path").Parse(output.ExportPath); err != nil {
+			return fmt.Errorf("invalid export path template: %w", err)
+		}
+	}
+
+	if err := wm.validateExportRetention(); err != nil {
+		return err
+	}
+
+	wm.fillColor = color.RGBA{A: 0xff}
+	if output.FillColor != "" {
+		fillColor, err := util.ParseHexColor(output.FillColor)
+		if err != nil {
+			return fmt.Errorf("invalid fill color %q: %w", output.FillColor, err)
+		}
+		wm.fillColor = fillColor
+	}
+
+	return nil
+}
+
+// fitImage scales an image to target dimensions according to configured fit mode
+func (wm *WallpaperManager) fitImage(img image.Image, targetWidth, targetHeight int, cropMode string, anchor *postprocess.Anchor) image.Image {
+	switch wm.WallpaperManagerConfig.Output.FitMode {
+	case postprocess.FitContain:
+		return postprocess.Contain(wm.quantize(img), targetWidth, targetHeight, wm.resampleFilter, wm.fillColor)
+	case postprocess.FitContainBlur:
+		return postprocess.ContainBlur(wm.quantize(img), targetWidth, targetHeight, wm.resampleFilter)
+	case postprocess.FitStretch:
+		return wm.resample(img, targetWidth, targetHeight)
+	default:
+		return wm.resizeImage(img, targetWidth, targetHeight, cropMode, anchor)
+	}
+}
+
+// exportPath returns the unresolved export path template. The command line
+// setting takes precedence over the profile.
+func (wm *WallpaperManager) exportPath() string {
+	if wm.WallpaperConfig.ExportPath != "" {
+		return wm.WallpaperConfig.ExportPath
+	}
+	return wm.WallpaperManagerConfig.Output.ExportPath
+}
+
+// resolveExportPath expands export path template. Relative paths are placed in
+// the app directory and paths without an image extension are treated as
+// directories.
+func (wm *WallpaperManager) resolveExportPath(pathTemplate string, now time.Time) (string, error) {
+	tmpl, err := template.New("export_path").Option("missingkey=error").Parse(pathTemplate)
+	if err != nil {
+		return "", fmt.Errorf("invalid export path template: %w", err)
+	}
+
+	profileType := filepath.Base(wm.configPath)
+	data := outputTemplateData{
+		Profile:   filepath.Base(filepath.Dir(wm.configPath)),
+		Type:      strings.TrimSuffix(profileType, filepath.Ext(profileType)),
+		Date:      now.Format("2006-01-02"),
+		Time:      now.Format("150405"),
+		Timestamp: now.Unix(),
+		Width:     wm.WallpaperConfig.TargetDimensions.Width,
+		Height:    wm.WallpaperConfig.TargetDimensions.Height,
+		Now:       now,
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render export path: %w", err)
+	}
+	path := buf.String()
+
+	if !filepath.IsAbs(path) {
+		appDirPath, err := util.GetAppDirPath()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(appDirPath, path)
+	}
+
+	if _, err := imaging.FormatFromFilename(path); err != nil {
+		path = filepath.Join(path, defaultOutputFileName)
+	}
+
+	return filepath.Clean(path), nil
+}
+
+// encodeOptions picks encoder settings for a file based on its extension
+func (wm *WallpaperManager) encodeOptions(path string) []imaging.EncodeOption {
+	output := wm.WallpaperManagerConfig.Output
+
+	format, err := imaging.FormatFromFilename(path)
+	if err != nil {
+		return nil
+	}
+
+	switch format {
+	case imaging.JPEG:
+		quality := output.JPEGQuality
+		if quality == 0 {
+			quality = DefaultJPEGQuality
+		}
+		return []imaging.EncodeOption{imaging.JPEGQuality(quality)}
+	case imaging.PNG:
+		return []imaging.EncodeOption{imaging.PNGCompressionLevel(pngCompressionLevels[output.PNGCompression])}
+	default:
+		return nil
+	}
+}
+
+// saveExport writes an additional copy of the rendered wallpaper to the
+// configured export path. The wallpaper itself is still applied from the
+// application directory. Clock updates only refresh the file written for the
+// current source frame, an export path that renders differently over time is
+// written again only on source updates.
+func (wm *WallpaperManager) saveExport(img image.Image, sourceUpdate bool) error {
+	pathTemplate := wm.exportPath()
+	if pathTemplate == "" {
+		return nil
+	}
+
+	path, err := wm.resolveExportPath(pathTemplate, time.Now())
+	if err != nil {
+		return err
+	}
+	if !sourceUpdate && path != wm.exportFilePath {
+		logger.WithField("path", path).Debug("Export path changed since last source update, skipping clock update")
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	if err := wm.saveTaggedImage(img, path, wm.encodeOptions(path)...); err != nil {
+		return fmt.Errorf("failed to save export image: %w", err)
+	}
+
+	wm.exportFilePath = path
+	logger.WithField("path", path).Debug("Export image saved")
+
+	if sourceUpdate {
+		wm.applyExportRetention(path)
+	}
+	return nil
+}
+
+// validateExportRetention rejects negative limits and retention for exports
+// outside the application directory, where unrelated files could be removed
+func (wm *WallpaperManager) validateExportRetention() error {
+	retention := wm.WallpaperManagerConfig.Output.ExportRetention
+	if retention.MaxFiles < 0 || retention.MaxAge < 0 || retention.MaxSizeMB < 0 {
+		return fmt.Errorf("export retention limits must not be negative")
+	}
+	if retention.policy().Enabled() && filepath.IsAbs(wm.exportPath()) {
+		return fmt.Errorf("export retention requires an export path inside the application directory: %q", wm.exportPath())
+	}
+	return nil
+}
+
+// applyExportRetention prunes exported files next to path
+func (wm *WallpaperManager) applyExportRetention(path string) {
+	policy := wm.WallpaperManagerConfig.Output.ExportRetention.policy()
+	if !policy.Enabled() {
+		return
+	}
+
+	janitor := repository.NewJanitor(filepath.Ext(path))
+	removed, err := janitor.ApplyRetention(filepath.Dir(path), policy, time.Now())
+	if err != nil {
+		logger.WithError(err).Warning("Failed to apply export retention")
+		return
+	}
+	if removed > 0 {
+		logger.WithField("removed", removed).Debug("Pruned exported wallpapers")
+	}
+}
+
+// saveImage encodes an image in the format matching its file extension and writes it atomically
+func saveImage(img image.Image, path string, opts ...imaging.EncodeOption) error {
+	format, err := imaging.FormatFromFilename(path)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteFileAtomic(path, func(w io.Writer) error {
+		return imaging.Encode(w, img, format, opts...)
+	})
+}
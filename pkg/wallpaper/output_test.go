@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveExportClockUpdates(t *testing.T) {
+	img := imaging.New(16, 16, color.NRGBA{90, 120, 150, 255})
+
+	t.Run("static path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "wallpaper.png")
+		wm := &WallpaperManager{}
+		wm.WallpaperManagerConfig.Output.ExportPath = path
+
+		require.NoError(t, wm.saveExport(img, true), "Source update should be saved")
+		require.NoError(t, os.Remove(path), "Removing output should succeed")
+		require.NoError(t, wm.saveExport(img, false), "Clock update should be saved")
+		assert.FileExists(t, path, "Clock updates should refresh a static export path")
+	})
+
+	t.Run("templated path", func(t *testing.T) {
+		dir := t.TempDir()
+		wm := &WallpaperManager{}
+		wm.WallpaperManagerConfig.Output.ExportPath = filepath.Join(dir, "{{.Now.UnixNano}}.png")
+
+		count := func() int {
+			files, err := os.ReadDir(dir)
+			require.NoError(t, err, "Reading output directory should succeed")
+			return len(files)
+		}
+
+		require.NoError(t, wm.saveExport(img, true), "Source update should be saved")
+		assert.Equal(t, 1, count(), "Source update should write one file")
+
+		for i := 0; i < 3; i++ {
+			require.NoError(t, wm.saveExport(img, false), "Clock update should not fail")
+		}
+		assert.Equal(t, 1, count(), "Clock updates should not write new files")
+
+		require.NoError(t, wm.saveExport(img, true), "Source update should be saved")
+		assert.Equal(t, 2, count(), "Next source update should write a new file")
+	})
+}
+
+func TestSaveExportRetention(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	appDir, err := util.GetAppDirPath()
+	require.NoError(t, err, "App directory should resolve")
+
+	img := imaging.New(16, 16, color.NRGBA{90, 120, 150, 255})
+	wm := &WallpaperManager{}
+	wm.WallpaperManagerConfig.Output.ExportPath = "exports/{{.Now.UnixNano}}.png"
+	wm.WallpaperManagerConfig.Output.ExportRetention = ExportRetention{MaxFiles: 2}
+
+	exportDir := filepath.Join(appDir, "exports")
+	notes := filepath.Join(exportDir, "notes.txt")
+	require.NoError(t, os.MkdirAll(exportDir, 0750), "Creating export directory should succeed")
+	require.NoError(t, os.WriteFile(notes, []byte("x"), 0600), "Writing unrelated file should succeed")
+
+	for i := 0; i < 4; i++ {
+		require.NoError(t, wm.saveExport(img, true), "Source update should be saved")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	files, err := filepath.Glob(filepath.Join(exportDir, "*.png"))
+	require.NoError(t, err, "Listing exports should succeed")
+	assert.Len(t, files, 2, "Retention should keep the newest exports")
+	assert.Contains(t, files, wm.exportFilePath, "Latest export should be kept")
+	assert.FileExists(t, notes, "Files of other types should be kept")
+}
+
+func TestValidateExportRetention(t *testing.T) {
+	absolute := filepath.Join(t.TempDir(), "{{.Time}}.jpg")
+	relative := filepath.Join("exports", "{{.Time}}.jpg")
+
+	tests := []struct {
+		name      string
+		path      string
+		retention ExportRetention
+		valid     bool
+	}{
+		{"disabled", absolute, ExportRetention{}, true},
+		{"relative path", relative, ExportRetention{MaxFiles: 10}, true},
+		{"absolute path", absolute, ExportRetention{MaxAge: time.Hour}, false},
+		{"negative limit", relative, ExportRetention{MaxSizeMB: -1}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wm := &WallpaperManager{}
+			wm.WallpaperManagerConfig.Output.ExportPath = tt.path
+			wm.WallpaperManagerConfig.Output.ExportRetention = tt.retention
+
+			err := wm.validateExportRetention()
+			if tt.valid {
+				assert.NoError(t, err, "Export retention should be accepted")
+			} else {
+				assert.Error(t, err, "Export retention should be rejected")
+			}
+		})
+	}
+}
+
+func TestPrepareOutputJPEGQuality(t *testing.T) {
+	for quality, valid := range map[int]bool{-1: false, 0: true, 1: true, 100: true, 101: false} {
+		wm := &WallpaperManager{}
+		wm.WallpaperManagerConfig.Output.JPEGQuality = quality
+
+		err := wm.prepareOutput()
+		if valid {
+			assert.NoError(t, err, "JPEG quality %d should be accepted", quality)
+		} else {
+			assert.ErrorContains(t, err, "or 0 for the default", "JPEG quality %d should be rejected", quality)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+)
+
+const (
+	StepCrop      = "crop"
+	StepEnhance   = "enhance"
+	StepSharpen   = "sharpen"
+	StepResize    = "resize"
+	StepBlur      = "blur"
+	StepNoise     = "noise"
+	StepWatermark = "watermark"
+)
+
+// PipelineStep describes a single named processing step of a profile pipeline.
+// Parameters left empty fall back to values from the image_processing and
+// input sections, so a step only needs to list what it overrides.
+type PipelineStep struct {
+	Step       string   `yaml:"step"`
+	Strength   *float64 `yaml:"strength"`
+	CropFactor *float64 `yaml:"crop_factor"`
+	OffsetX    *float64 `yaml:"offset_x"`
+	OffsetY    *float64 `yaml:"offset_y"`
+	MaxOpacity *float64 `yaml:"max_opacity"`
+	Scale      *int     `yaml:"scale"`
+	Width      *int     `yaml:"width"`
+	Height     *int     `yaml:"height"`
+}
+
+// pipelineContext carries state shared between steps of a single pipeline run
+type pipelineContext struct {
+	original image.Image
+	input    InputSource
+	tiles    []image.Image
+
+	originalStats *postprocess.ImageStats
+}
+
+// sourceStats measures the original frame on first use
+func (ctx *pipelineContext) sourceStats() *postprocess.ImageStats {
+	if ctx.originalStats == nil {
+		ctx.originalStats = postprocess.MeasureImage(ctx.original, postprocess.MeasureOptions{})
+	}
+	return ctx.originalStats
+}
+
+type pipelineStage func(wm *WallpaperManager, img image.Image, step PipelineStep, ctx *pipelineContext) (image.Image, error)
+
+var pipelineStages = map[string]pipelineStage{
+	StepCrop: func(wm *WallpaperManager, img image.Image, step PipelineStep, ctx *pipelineContext) (image.Image, error) {
+		factor := floatOrDefault(step.CropFactor, ctx.input.CropFactor)
+		offsetX := floatOrDefault(step.OffsetX, ctx.input.OffsetX)
+		offsetY := floatOrDefault(step.OffsetY, ctx.input.OffsetY)
+		return wm.cropImage(img, factor, offsetX, offsetY, ctx.input.CropMode, ctx.input.Anchor), nil
+	},
+	StepEnhance: func(wm *WallpaperManager, img image.Image, step PipelineStep, ctx *pipelineContext) (image.Image, error) {
+		return wm.enhanceImage(img, wm.WallpaperManagerConfig.ImageProcessing.EnhancementConfig), nil
+	},
+	StepSharpen: func(wm *WallpaperManager, img image.Image, step PipelineStep, ctx *pipelineContext) (image.Image, error) {
+		return wm.sharpenImage(img, floatOrDefault(step.Strength, wm.WallpaperManagerConfig.ImageProcessing.SharpenStrength)), nil
+	},
+	StepResize: func(wm *WallpaperManager, img image.Image, step PipelineStep, ctx *pipelineContext) (image.Image, error) {
+		width := intOrDefault(step.Width, wm.WallpaperConfig.TargetDimensions.Width)
+		height := intOrDefault(step.Height, wm.WallpaperConfig.TargetDimensions.Height)
+		return wm.fitImage(img, width, height, ctx.input.CropMode, ctx.input.Anchor), nil
+	},
+	StepBlur: func(wm *WallpaperManager, img image.Image, step PipelineStep, ctx *pipelineContext) (image.Image, error) {
+		return wm.applyBlur(img, floatOrDefault(step.Strength, wm.WallpaperManagerConfig.ImageProcessing.BlurStrength)), nil
+	},
+	StepNoise: func(wm *WallpaperManager, img image.Image, step PipelineStep, ctx *pipelineContext) (image.Image, error) {
+		maxOpacity := floatOrDefault(step.MaxOpacity, wm.WallpaperManagerConfig.ImageProcessing.MaxNoiseOpacity)
+		scale := intOrDefault(step.Scale, wm.WallpaperManagerConfig.ImageProcessing.NoiseScale)
+		return wm.applyNoise(img, maxOpacity, scale), nil
+	},
+	StepWatermark: func(wm *WallpaperManager, img image.Image, step PipelineStep, ctx *pipelineContext) (image.Image, error) {
+		return wm.applyWatermark(img, ctx.sourceStats()), nil
+	},
+	StepComposite: func(wm *WallpaperManager, img image.Image, step PipelineStep, ctx *pipelineContext) (image.Image, error) {
+		if len(ctx.tiles) == 0 {
+			return nil, fmt.Errorf("no composite tiles available")
+		}
+		return wm.composeTiles(ctx.tiles), nil
+	},
+}
+
+// DefaultPipeline returns the processing order used when a profile does not define one
+func DefaultPipeline() []PipelineStep {
+	return []PipelineStep{
+		{Step: StepCrop},
+		{Step: StepEnhance},
+		{Step: StepSharpen},
+		{Step: StepResize},
+		{Step: StepBlur},
+		{Step: StepNoise},
+		{Step: StepWatermark},
+	}
+}
+
+// DefaultCompositePipeline returns the processing order used for composite
+// profiles that do not define one. Tiles are cropped and enhanced individually
+// by the composite step.
+func DefaultCompositePipeline() []PipelineStep {
+	return []PipelineStep{
+		{Step: StepComposite},
+		{Step: StepSharpen},
+		{Step: StepBlur},
+		{Step: StepNoise},
+		{Step: StepWatermark},
+	}
+}
+
+func (wm *WallpaperManager) pipeline() []PipelineStep {
+	if len(wm.WallpaperManagerConfig.Pipeline) == 0 {
+		if wm.compositeEnabled() {
+			return DefaultCompositePipeline()
+		}
+		return DefaultPipeline()
+	}
+	return wm.WallpaperManagerConfig.Pipeline
+}
+
+// validatePipeline rejects unknown steps. Steps may run in any order and
+// more than once, only composite replaces the frame and must come first.
+func (wm *WallpaperManager) validatePipeline() error {
+	for i, step := range wm.WallpaperManagerConfig.Pipeline {
+		if _, ok := pipelineStages[step.Step]; !ok {
+			return fmt.Errorf("unknown pipeline step at position %d: %q", i, step.Step)
+		}
+		if step.Step == StepComposite && i > 0 {
+			return fmt.Errorf("pipeline step %q must come first, found at position %d", step.Step, i)
+		}
+	}
+	return nil
+}
+
+func (wm *WallpaperManager) runPipeline(ctx *pipelineContext) (image.Image, error) {
+	img := ctx.original
+
+	var err error
+	for _, step := range wm.pipeline() {
+		stage, ok := pipelineStages[step.Step]
+		if !ok {
+			return nil, fmt.Errorf("unknown pipeline step: %q", step.Step)
+		}
+
+		if !precisionSteps[step.Step] {
+			img = wm.quantize(img)
+		}
+
+		logger.WithField("step", step.Step).Debug("Running pipeline step")
+		img, err = stage(wm, img, step, ctx)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline step %s failed: %w", step.Step, err)
+		}
+	}
+
+	return wm.quantize(img), nil
+}
+
+func floatOrDefault(value *float64, fallback float64) float64 {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+func intOrDefault(value *int, fallback int) int {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePipeline(t *testing.T) {
+	steps := func(names ...string) []PipelineStep {
+		pipeline := make([]PipelineStep, len(names))
+		for i, name := range names {
+			pipeline[i] = PipelineStep{Step: name}
+		}
+		return pipeline
+	}
+
+	tests := []struct {
+		name     string
+		pipeline []PipelineStep
+		err      string
+	}{
+		{"empty", nil, ""},
+		{"default", DefaultPipeline(), ""},
+		{"default composite", DefaultCompositePipeline(), ""},
+		{"resize before crop", steps(StepResize, StepCrop, StepWatermark), ""},
+		{"blur after watermark", steps(StepCrop, StepWatermark, StepBlur, StepSharpen), ""},
+		{"watermark before noise", steps(StepResize, StepWatermark, StepNoise), ""},
+		{"unknown step", steps(StepCrop, "vignette"), "unknown pipeline step"},
+		{"repeated step", steps(StepSharpen, StepBlur, StepSharpen), ""},
+		{"resize after watermark", steps(StepCrop, StepWatermark, StepResize), ""},
+		{"noise before resize", steps(StepCrop, StepNoise, StepResize), ""},
+		{"crop after noise", steps(StepNoise, StepCrop), ""},
+		{"composite not first", steps(StepEnhance, StepComposite), "must come first"},
+		{"composite after watermark", steps(StepWatermark, StepComposite), "must come first"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wm := &WallpaperManager{}
+			wm.WallpaperManagerConfig.Pipeline = tt.pipeline
+
+			err := wm.validatePipeline()
+			if tt.err == "" {
+				assert.NoError(t, err, "Pipeline should be accepted")
+			} else {
+				assert.ErrorContains(t, err, tt.err, "Pipeline should be rejected")
+			}
+		})
+	}
+}
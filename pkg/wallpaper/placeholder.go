@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/disintegration/imaging"
+)
+
+const (
+	defaultPlaceholderThreshold = 6
+)
+
+var (
+	placeholderPatterns = []string{"placeholder*.png", "placeholder*.jpg", "placeholder*.jpeg"}
+)
+
+type placeholderReference struct {
+	name string
+	hash uint64
+}
+
+// placeholderReferences loads reference placeholder images shipped in the
+// profile directory once and caches their hashes
+func (wm *WallpaperManager) placeholderReferences() []placeholderReference {
+	if wm.placeholders != nil {
+		return wm.placeholders
+	}
+
+	wm.placeholders = []placeholderReference{}
+	profileDir := filepath.Dir(wm.configPath)
+	for _, pattern := range placeholderPatterns {
+		matches, err := filepath.Glob(filepath.Join(profileDir, pattern))
+		if err != nil {
+			continue
+		}
+
+		for _, match := range matches {
+			img, err := imaging.Open(match)
+			if err != nil {
+				logger.WithError(err).WithField("path", match).Warn("Failed to open placeholder reference")
+				continue
+			}
+			wm.placeholders = append(wm.placeholders, placeholderReference{
+				name: filepath.Base(match),
+				hash: postprocess.DifferenceHash(img),
+			})
+		}
+	}
+
+	logger.WithField("count", len(wm.placeholders)).Debug("Placeholder references loaded")
+	return wm.placeholders
+}
+
+// rejectPlaceholder returns an error if a downloaded frame looks like one of
+// the profile's known offline placeholders
+func (wm *WallpaperManager) rejectPlaceholder(img image.Image) error {
+	references := wm.placeholderReferences()
+	if len(references) == 0 {
+		return nil
+	}
+
+	threshold := wm.WallpaperManagerConfig.Input.PlaceholderThreshold
+	if threshold <= 0 {
+		threshold = defaultPlaceholderThreshold
+	}
+
+	hash := postprocess.DifferenceHash(img)
+	for _, reference := range references {
+		if distance := postprocess.HammingDistance(hash, reference.hash); distance <= threshold {
+			return fmt.Errorf("frame matches placeholder %s (distance %d)", reference.name, distance)
+		}
+	}
+
+	return nil
+}
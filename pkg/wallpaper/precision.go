@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"image"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/disintegration/imaging"
+)
+
+// precisionSteps lists pipeline steps that keep 16 bits per channel. Other
+// steps receive a dithered 8-bit image.
+var precisionSteps = map[string]bool{
+	StepCrop:    true,
+	StepEnhance: true,
+	StepResize:  true,
+	StepBlur:    true,
+}
+
+// highPrecision reports whether the profile processes in 16 bits per channel
+// and dithers on quantization
+func (wm *WallpaperManager) highPrecision() bool {
+	switch wm.WallpaperManagerConfig.Output.Dither {
+	case postprocess.DitherOrdered, postprocess.DitherBlueNoise:
+		return true
+	default:
+		return wm.linearLight()
+	}
+}
+
+// quantize dithers high precision images down to 8 bits per channel
+func (wm *WallpaperManager) quantize(img image.Image) image.Image {
+	if _, ok := img.(*image.NRGBA64); !ok {
+		return img
+	}
+	return postprocess.Dither(img, wm.WallpaperManagerConfig.Output.Dither)
+}
+
+// resample scales img with the configured filter, in 16 bits or linear light when enabled
+func (wm *WallpaperManager) resample(img image.Image, width, height int) image.Image {
+	if wm.linearLight() {
+		return postprocess.FromLinear(postprocess.Resize64(postprocess.ToLinear(img), width, height, wm.resampleFilter))
+	}
+	if wm.highPrecision() {
+		return postprocess.Resize64(img, width, height, wm.resampleFilter)
+	}
+	return imaging.Resize(img, width, height, wm.resampleFilter)
+}
+
+// crop cuts rect out of img without dropping 16-bit precision
+func (wm *WallpaperManager) crop(img image.Image, rect image.Rectangle) image.Image {
+	if src, ok := img.(*image.NRGBA64); ok {
+		return postprocess.Crop64(src, rect.Add(src.Bounds().Min))
+	}
+	return imaging.Crop(img, rect)
+}
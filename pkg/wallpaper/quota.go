@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/repository"
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// activeURLHashes returns names of files/<hash> directories used by current profile
+func (wm *WallpaperManager) activeURLHashes() []string {
+	var urls []string
+	for _, source := range wm.sources() {
+		urls = append(urls, source.URL)
+	}
+	for _, tile := range wm.WallpaperManagerConfig.Composite.Tiles {
+		urls = append(urls, tile.URL)
+	}
+	urls = append(urls, wm.primaryURL())
+
+	hashes := make([]string, 0, len(urls))
+	for _, url := range urls {
+		hashes = append(hashes, util.GenerateShortHash(url, ""))
+	}
+	return hashes
+}
+
+// collectGarbage reports disk usage per source directory, removes directories
+// of profiles that have not been used for a while and evicts the least
+// recently used directories once the cache quota is exceeded
+func (wm *WallpaperManager) collectGarbage(filesPath string) {
+	janitor := repository.NewJanitor(FileType)
+
+	usage, err := janitor.Usage(filesPath)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to measure disk usage")
+		return
+	}
+	for _, dirUsage := range usage {
+		logger.WithFields(logrus.Fields{
+			"directory": dirUsage.Name,
+			"bytes":     dirUsage.Bytes,
+			"files":     dirUsage.Files,
+		}).Debug("Disk usage")
+	}
+
+	quota := wm.WallpaperConfig.CacheQuota
+	active := wm.activeURLHashes()
+
+	if quota.OrphanMaxAge > 0 {
+		removed, err := janitor.CollectOrphans(filesPath, active, quota.OrphanMaxAge, time.Now())
+		if err != nil {
+			logger.WithError(err).Warn("Failed to remove orphaned directories")
+		}
+		for _, dirUsage := range removed {
+			logger.WithFields(logrus.Fields{
+				"directory": filepath.Base(dirUsage.Path),
+				"bytes":     dirUsage.Bytes,
+			}).Info("Removed orphaned directory")
+		}
+	}
+
+	evicted, err := janitor.EnforceQuota(filesPath, quota.MaxBytes, active)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to enforce cache quota")
+	}
+	for _, dirUsage := range evicted {
+		logger.WithFields(logrus.Fields{
+			"directory": filepath.Base(dirUsage.Path),
+			"bytes":     dirUsage.Bytes,
+		}).Info("Evicted directory to fit cache quota")
+	}
+}
@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultReprobeIntervalMinutes = 60
+)
+
+// InputSource is a single webcam URL together with its own crop settings
+type InputSource struct {
+	URL        string              `yaml:"url"`
+	CropFactor float64             `yaml:"crop_factor"`
+	OffsetX    float64             `yaml:"offset_x"`
+	OffsetY    float64             `yaml:"offset_y"`
+	CropMode   string              `yaml:"crop_mode"`
+	Anchor     *postprocess.Anchor `yaml:"anchor"`
+}
+
+// sourceState remembers which source last delivered a frame and when the
+// preferred source was last probed
+type sourceState struct {
+	activeIndex int
+	lastProbe   time.Time
+}
+
+// sources returns the configured source list in order of preference. Profiles
+// without an input.sources list are treated as a single source built from the
+// legacy input.url fields.
+func (wm *WallpaperManager) sources() []InputSource {
+	input := wm.WallpaperManagerConfig.Input
+	if len(input.Sources) > 0 {
+		return input.Sources
+	}
+
+	return []InputSource{{
+		URL:        input.URL,
+		CropFactor: input.CropFactor,
+		OffsetX:    input.OffsetX,
+		OffsetY:    input.OffsetY,
+		CropMode:   input.CropMode,
+		Anchor:     input.Anchor,
+	}}
+}
+
+func (wm *WallpaperManager) validateSources() error {
+	sources := wm.sources()
+	for _, tile := range wm.WallpaperManagerConfig.Composite.Tiles {
+		sources = append(sources, tile.InputSource)
+	}
+
+	for _, source := range sources {
+		switch source.CropMode {
+		case "", postprocess.CropModeCenter, postprocess.CropModeSmart:
+		default:
+			return fmt.Errorf("unknown crop mode for %s: %q", source.URL, source.CropMode)
+		}
+	}
+	return nil
+}
+
+// primaryURL identifies the profile on disk, independent of the source that is currently active
+func (wm *WallpaperManager) primaryURL() string {
+	if wm.compositeEnabled() {
+		return wm.WallpaperManagerConfig.Composite.Tiles[0].URL
+	}
+	return wm.sources()[0].URL
+}
+
+func (wm *WallpaperManager) reprobeInterval() time.Duration {
+	minutes := wm.WallpaperManagerConfig.Input.ReprobeIntervalMinutes
+	if minutes <= 0 {
+		minutes = DefaultReprobeIntervalMinutes
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// sourceOrder returns source indices in the order they should be tried. The
+// last successful source goes first, unless it is time to re-probe the
+// preferred one.
+func (wm *WallpaperManager) sourceOrder(count int) []int {
+	start := wm.sourceState.activeIndex
+	if start >= count {
+		start = 0
+	}
+
+	if start != 0 && time.Since(wm.sourceState.lastProbe) >= wm.reprobeInterval() {
+		logger.WithField("activeSource", start).Info("Re-probing preferred source")
+		start = 0
+	}
+	if start == 0 {
+		wm.sourceState.lastProbe = time.Now()
+	}
+
+	order := make([]int, 0, count)
+	for i := 0; i < count; i++ {
+		order = append(order, (start+i)%count)
+	}
+	return order
+}
+
+// validateFrame rejects downloaded frames that decode fine but should not
+// become a wallpaper, such as offline placeholders or corrupt, dark and
+// blurry frames
+func (wm *WallpaperManager) validateFrame(imagePath string) error {
+	img, err := wm.openFrame(imagePath)
+	if err != nil {
+		return fmt.Errorf("failed to open image: %w", err)
+	}
+
+	if err := wm.rejectPlaceholder(img); err != nil {
+		return err
+	}
+
+	if err := wm.WallpaperManagerConfig.Input.QualityGate.Evaluate(img); err != nil {
+		return fmt.Errorf("quality gate: %w", err)
+	}
+
+	return nil
+}
+
+// fetchSource downloads and sanitizes a frame from first source that delivers one
+func (wm *WallpaperManager) fetchSource(tempImageFilePath string) (InputSource, error) {
+	sources := wm.sources()
+
+	var lastErr error
+	for _, i := range wm.sourceOrder(len(sources)) {
+		source := sources[i]
+		sourceLogger := logger.WithFields(logrus.Fields{
+			"source": i,
+			"url":    source.URL,
+		})
+
+		sourceLogger.Debug("Fetching new image from source")
+		if err := util.DownloadImage(source.URL, tempImageFilePath, false); err != nil {
+			sourceLogger.WithError(err).Warn("Failed to download image")
+			lastErr = err
+			continue
+		}
+
+		sourceLogger.Debug("Sanitizing downloaded image")
+		if err := wm.sanitizeFrame(tempImageFilePath); err != nil {
+			sourceLogger.WithError(err).Warn("Failed to sanitize image")
+			lastErr = err
+			continue
+		}
+
+		if err := wm.validateFrame(tempImageFilePath); err != nil {
+			sourceLogger.WithField("reason", err.Error()).Warn("Rejected downloaded frame")
+			lastErr = err
+			continue
+		}
+
+		if i != wm.sourceState.activeIndex {
+			sourceLogger.WithField("previousSource", wm.sourceState.activeIndex).Info("Switched active source")
+		}
+		wm.sourceState.activeIndex = i
+
+		return source, nil
+	}
+
+	return InputSource{}, fmt.Errorf("all %d sources failed: %w", len(sources), lastErr)
+}
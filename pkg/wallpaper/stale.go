@@ -0,0 +1,235 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+)
+
+const (
+	StaleActionKeep  = "keep"
+	StaleActionBadge = "badge"
+	StaleActionSkip  = "skip"
+
+	frameHashFileName     = "frame_hashes"
+	defaultStaleHistory   = 10
+	defaultStaleThreshold = 2
+	staleBadgeText        = "stale"
+	staleBadgeFontSize    = 14
+	staleBadgeOpacity     = 0.35
+)
+
+var (
+	errStaleSource = errors.New("source is stale")
+)
+
+// StaleDetectionConfig controls frozen frame detection. Detection is enabled
+// when Cycles is set.
+type StaleDetectionConfig struct {
+	Cycles    int    `yaml:"cycles"`
+	Threshold int    `yaml:"threshold"`
+	History   int    `yaml:"history"`
+	Action    string `yaml:"action"`
+}
+
+func (wm *WallpaperManager) staleDetectionEnabled() bool {
+	return wm.WallpaperManagerConfig.Input.StaleDetection.Cycles > 0 && !wm.compositeEnabled()
+}
+
+func (wm *WallpaperManager) validateStaleDetection() error {
+	switch wm.WallpaperManagerConfig.Input.StaleDetection.Action {
+	case "", StaleActionKeep, StaleActionBadge, StaleActionSkip:
+		return nil
+	default:
+		return fmt.Errorf("unknown stale action: %q", wm.WallpaperManagerConfig.Input.StaleDetection.Action)
+	}
+}
+
+// frameRecord is a perceptual hash of a downloaded frame together with the
+// index of the source that delivered it
+type frameRecord struct {
+	source int
+	hash   uint64
+}
+
+func frameHashFilePath(url string) (string, error) {
+	appDirPath, err := util.GetAppDirPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(appDirPath, "files", util.GenerateShortHash(url, ""), frameHashFileName), nil
+}
+
+func loadFrameHashes(path string) ([]frameRecord, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []frameRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid frame hash record %q", line)
+		}
+		source, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid frame source %q: %w", fields[0], err)
+		}
+		hash, err := strconv.ParseUint(fields[1], 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid frame hash %q: %w", fields[1], err)
+		}
+		records = append(records, frameRecord{source: source, hash: hash})
+	}
+
+	return records, scanner.Err()
+}
+
+func saveFrameHashes(path string, records []frameRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+
+	var builder strings.Builder
+	for _, record := range records {
+		builder.WriteString(strconv.Itoa(record.source))
+		builder.WriteString(" ")
+		builder.WriteString(strconv.FormatUint(record.hash, 16))
+		builder.WriteString("\n")
+	}
+
+	return util.WriteFileAtomic(path, func(w io.Writer) error {
+		_, err := io.WriteString(w, builder.String())
+		return err
+	})
+}
+
+// unchangedCycles counts how many frames before the newest one came from the
+// same source and are perceptually identical to it
+func unchangedCycles(records []frameRecord, threshold int) int {
+	if len(records) < 2 {
+		return 0
+	}
+
+	newest := records[len(records)-1]
+	count := 0
+	for i := len(records) - 2; i >= 0; i-- {
+		if records[i].source != newest.source || postprocess.HammingDistance(newest.hash, records[i].hash) > threshold {
+			break
+		}
+		count++
+	}
+	return count
+}
+
+// recordFrame hashes a freshly downloaded frame, persists it together with the
+// active source to the profile's hash history and returns the number of
+// consecutive unchanged cycles. History is kept next to the processed frame
+// cache, so a source switch is never mistaken for an unchanged frame.
+func (wm *WallpaperManager) recordFrame(img image.Image) (int, error) {
+	config := wm.WallpaperManagerConfig.Input.StaleDetection
+
+	history := config.History
+	if history <= 0 {
+		history = defaultStaleHistory
+	}
+	if history < config.Cycles+1 {
+		history = config.Cycles + 1
+	}
+
+	threshold := config.Threshold
+	if threshold <= 0 {
+		threshold = defaultStaleThreshold
+	}
+
+	path, err := frameHashFilePath(wm.primaryURL())
+	if err != nil {
+		return 0, err
+	}
+
+	records, err := loadFrameHashes(path)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to load frame hash history, starting over")
+		records = nil
+	}
+
+	records = append(records, frameRecord{
+		source: wm.sourceState.activeIndex,
+		hash:   postprocess.DifferenceHash(img),
+	})
+	if len(records) > history {
+		records = records[len(records)-history:]
+	}
+
+	if err := saveFrameHashes(path, records); err != nil {
+		return 0, err
+	}
+
+	return unchangedCycles(records, threshold), nil
+}
+
+// checkStale updates stale state for a downloaded frame. It returns true when
+// the previous processed frame should be reused instead of reprocessing, which
+// requires it to come from the same source as the downloaded frame.
+func (wm *WallpaperManager) checkStale(source InputSource, img image.Image, previousProcImageFilePath string) (bool, error) {
+	config := wm.WallpaperManagerConfig.Input.StaleDetection
+
+	unchanged, err := wm.recordFrame(img)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to record frame hash")
+		return false, nil
+	}
+
+	wasStale := wm.sourceStale
+	wm.sourceStale = unchanged >= config.Cycles
+
+	if !wm.sourceStale {
+		if wasStale {
+			logger.WithField("url", source.URL).Info("Source delivers new frames again")
+		}
+		return unchanged > 0 && !wm.WallpaperManagerConfig.Output.Blend.enabled() && util.FileExists(previousProcImageFilePath), nil
+	}
+
+	logger.WithField("url", source.URL).WithField("unchangedCycles", unchanged).WithField("action", config.Action).Warn("Source is stale")
+
+	if config.Action == StaleActionSkip {
+		return false, errStaleSource
+	}
+	return util.FileExists(previousProcImageFilePath), nil
+}
+
+func (wm *WallpaperManager) applyStaleBadge(img image.Image) image.Image {
+	if !wm.sourceStale || wm.WallpaperManagerConfig.Input.StaleDetection.Action != StaleActionBadge {
+		return img
+	}
+
+	badged, err := wm.frameState.clock.DrawBadge(img, staleBadgeText, staleBadgeFontSize, staleBadgeOpacity)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to draw stale badge")
+		return img
+	}
+	return badged
+}
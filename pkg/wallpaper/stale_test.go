@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"image/color"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnchangedCycles(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []frameRecord
+		want    int
+	}{
+		{"empty", nil, 0},
+		{"single frame", []frameRecord{{0, 0xff}}, 0},
+		{"unchanged", []frameRecord{{0, 0xff}, {0, 0xff}, {0, 0xfe}}, 2},
+		{"changed", []frameRecord{{0, 0xff}, {0, 0x00}}, 0},
+		{"source switch", []frameRecord{{0, 0xff}, {0, 0xff}, {1, 0xff}}, 0},
+		{"after source switch", []frameRecord{{0, 0xff}, {1, 0xff}, {1, 0xff}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, unchangedCycles(tt.records, 2), "Unchanged cycles should match")
+		})
+	}
+}
+
+func TestCheckStaleSourceSwitch(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	previous := filepath.Join(t.TempDir(), "cache.png")
+	img := imaging.New(32, 32, color.NRGBA{90, 120, 150, 255})
+	require.NoError(t, imaging.Save(img, previous), "Saving previous frame should succeed")
+
+	wm := &WallpaperManager{}
+	wm.WallpaperManagerConfig.Input.Sources = []InputSource{{URL: "https://example.com/a.jpg"}, {URL: "https://example.com/b.jpg"}}
+	wm.WallpaperManagerConfig.Input.StaleDetection = StaleDetectionConfig{Cycles: 3}
+	source := wm.sources()[0]
+
+	reuse, err := wm.checkStale(source, img, previous)
+	require.NoError(t, err, "First frame should be recorded")
+	assert.False(t, reuse, "First frame should be processed")
+
+	reuse, err = wm.checkStale(source, img, previous)
+	require.NoError(t, err, "Unchanged frame should be recorded")
+	assert.True(t, reuse, "Unchanged frame of same source should reuse previous frame")
+
+	wm.sourceState.activeIndex = 1
+	reuse, err = wm.checkStale(wm.sources()[1], img, previous)
+	require.NoError(t, err, "Frame of fallback source should be recorded")
+	assert.False(t, reuse, "Frame of another source should not reuse previous frame")
+
+	path, err := frameHashFilePath(wm.primaryURL())
+	require.NoError(t, err, "History path should resolve")
+	records, err := loadFrameHashes(path)
+	require.NoError(t, err, "History should load")
+	require.Len(t, records, 3, "Every frame should be recorded")
+	assert.Equal(t, []int{0, 0, 1}, []int{records[0].source, records[1].source, records[2].source}, "Sources should be stored with hashes")
+}
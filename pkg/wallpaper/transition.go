@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+	"github.com/disintegration/imaging"
+)
+
+const (
+	DefaultTransitionSteps = 8
+	maxTransitionSeconds   = 10
+	maxTransitionSteps     = 30
+)
+
+// TransitionConfig crossfades from the previous to the new wallpaper. The
+// transition is enabled when DurationSeconds is set.
+type TransitionConfig struct {
+	DurationSeconds float64 `yaml:"duration_seconds"`
+	Steps           int     `yaml:"steps"`
+}
+
+func (wm *WallpaperManager) transitionEnabled() bool {
+	return wm.WallpaperManagerConfig.Output.Transition.DurationSeconds > 0 && !wm.WallpaperConfig.DisableOSWallpaperUpdate
+}
+
+func (wm *WallpaperManager) validateTransition() error {
+	transition := wm.WallpaperManagerConfig.Output.Transition
+	if transition.DurationSeconds < 0 || transition.DurationSeconds > maxTransitionSeconds {
+		return fmt.Errorf("transition duration must be between 0 and %d seconds: %v", maxTransitionSeconds, transition.DurationSeconds)
+	}
+	if transition.Steps < 0 || transition.Steps > maxTransitionSteps {
+		return fmt.Errorf("transition steps must be between 0 and %d: %d", maxTransitionSteps, transition.Steps)
+	}
+	return nil
+}
+
+// loadTransitionBase returns the previously processed frame to fade from, or nil
+func (wm *WallpaperManager) loadTransitionBase(previousProcImageFilePath string) image.Image {
+	if !wm.transitionEnabled() || !util.FileExists(previousProcImageFilePath) {
+		return nil
+	}
+
+	previousImage, err := wm.currentFrame(previousProcImageFilePath)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to load previous frame for transition")
+		return nil
+	}
+	return previousImage
+}
+
+// playTransition applies intermediate frames between from and to as wallpaper,
+// spread evenly over the configured duration. The final frame is left to the
+// regular update. Returned frame files stay in place until the final frame is
+// applied, see removeTransitionFrames.
+func (wm *WallpaperManager) playTransition(from, to image.Image, tempImagePath string) []string {
+	if from == nil || to == nil || from.Bounds().Size() != to.Bounds().Size() {
+		return nil
+	}
+
+	transition := wm.WallpaperManagerConfig.Output.Transition
+	steps := transition.Steps
+	if steps <= 0 {
+		steps = DefaultTransitionSteps
+	}
+	interval := time.Duration(transition.DurationSeconds * float64(time.Second) / float64(steps))
+
+	var framePaths []string
+	logger.WithField("steps", steps).WithField("interval", interval.String()).Debug("Playing transition")
+	for i := 1; i < steps; i++ {
+		frameStart := time.Now()
+
+		frame, err := wm.renderTransitionFrame(wm.overlay(from, to, image.Pt(0, 0), float64(i)/float64(steps)))
+		if err != nil {
+			logger.WithError(err).Warn("Failed to render transition frame")
+			return framePaths
+		}
+
+		// Unique names, some desktops ignore updates to an unchanged wallpaper path
+		framePath := filepath.Join(tempImagePath, fmt.Sprintf("transition_%d_%d%s", time.Now().UnixNano(), i, FileType))
+		if err := wm.saveTaggedImage(frame, framePath, imaging.PNGCompressionLevel(png.NoCompression)); err != nil {
+			logger.WithError(err).Warn("Failed to save transition frame")
+			return framePaths
+		}
+		framePaths = append(framePaths, framePath)
+
+		if err := wm.setWallpaper(framePath); err != nil {
+			logger.WithError(err).Warn("Failed to apply transition frame")
+			return framePaths
+		}
+
+		// Rendering counts towards the interval, so the transition keeps its duration
+		time.Sleep(interval - time.Since(frameStart))
+	}
+	return framePaths
+}
+
+// removeTransitionFrames deletes transition frame files. Call it only once
+// the desktop no longer shows any of them.
+func removeTransitionFrames(framePaths []string) {
+	for _, path := range framePaths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.WithError(err).WithField("path", path).Warn("Failed to remove transition frame")
+		}
+	}
+}
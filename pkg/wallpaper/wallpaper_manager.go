@@ -5,21 +5,22 @@
 package wallpaper
 
 import (
+	"errors"
 	"fmt"
 	"image"
-	"image/jpeg"
+	"image/color"
 	"image/png"
 	"math"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/TilmanGriesel/AlpineZen/pkg/logging"
 	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
 	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess/adjustment"
 	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess/render"
-	"github.com/TilmanGriesel/AlpineZen/pkg/sanitizer"
 	"github.com/sirupsen/logrus"
 
 	"github.com/TilmanGriesel/AlpineZen/pkg/repository"
@@ -44,6 +45,19 @@ type WallpaperManager struct {
 	noiseImg               *image.NRGBA
 	configPath             string
 	updateCount            int
+	sourceState            sourceState
+	sourceStale            bool
+	placeholders           []placeholderReference
+	watermark              image.Image
+	watermarkLoaded        bool
+	luts                   map[string]*adjustment.LUT3D
+	colorSpaceHints        map[string]string
+	frameState             frameState
+	exportFilePath         string
+	resampleFilter         imaging.ResampleFilter
+	fillColor              color.Color
+	updateLock             sync.Mutex
+	shutdown               bool
 }
 
 type WallpaperConfig struct {
@@ -51,6 +65,9 @@ type WallpaperConfig struct {
 	DisableOSWallpaperUpdate bool
 	TargetDimensions         Dimensions
 	FontConfigClock          render.FontConfig
+	ExportPath               string
+	ArchiveRetention         repository.RetentionPolicy
+	CacheQuota               repository.QuotaPolicy
 }
 
 type Dimensions struct {
@@ -60,32 +77,68 @@ type Dimensions struct {
 
 type WallpaperManagerConfig struct {
 	Input struct {
-		URL        string  `yaml:"url"`
-		CropFactor float64 `yaml:"crop_factor"`
-		OffsetX    float64 `yaml:"offset_x"`
-		OffsetY    float64 `yaml:"offset_y"`
+		URL                    string                  `yaml:"url"`
+		CropFactor             float64                 `yaml:"crop_factor"`
+		OffsetX                float64                 `yaml:"offset_x"`
+		OffsetY                float64                 `yaml:"offset_y"`
+		CropMode               string                  `yaml:"crop_mode"`
+		Anchor                 *postprocess.Anchor     `yaml:"anchor"`
+		Sources                []InputSource           `yaml:"sources"`
+		ReprobeIntervalMinutes int                     `yaml:"reprobe_interval_minutes"`
+		StaleDetection         StaleDetectionConfig    `yaml:"stale_detection"`
+		PlaceholderThreshold   int                     `yaml:"placeholder_threshold"`
+		QualityGate            postprocess.QualityGate `yaml:"quality_gate"`
 	} `yaml:"input"`
 	ImageProcessing struct {
-		Contrast        float64 `yaml:"contrast"`
-		Saturation      float64 `yaml:"saturation"`
-		Brightness      float64 `yaml:"brightness"`
-		Hue             float64 `yaml:"hue"`
-		Gamma           float64 `yaml:"gamma"`
-		BlackPoint      float64 `yaml:"black_point"`
-		WhitePoint      float64 `yaml:"white_point"`
-		ShadowStrength  float64 `yaml:"shadow_strength"`
-		BlurStrength    float64 `yaml:"blur_strength"`
-		SharpenStrength float64 `yaml:"sharpen_strength"`
-		MaxNoiseOpacity float64 `yaml:"max_noise_opacity"`
-		NoiseScale      int     `yaml:"noise_scale"`
+		EnhancementConfig `yaml:",inline"`
+		BlurStrength      float64 `yaml:"blur_strength"`
+		SharpenStrength   float64 `yaml:"sharpen_strength"`
+		MaxNoiseOpacity   float64 `yaml:"max_noise_opacity"`
+		NoiseScale        int     `yaml:"noise_scale"`
+		LinearLight       bool    `yaml:"linear_light"`
 	} `yaml:"image_processing"`
 	Scheduling struct {
 		UpdateIntervalMinutes int `yaml:"update_interval_minutes"`
 	} `yaml:"scheduling"`
 	Output struct {
-		Blend    bool   `yaml:"blend"`
-		SavePath string `yaml:"save_path"`
+		Blend           BlendConfig      `yaml:"blend"`
+		Transition      TransitionConfig `yaml:"transition"`
+		ExportPath      string           `yaml:"export_path"`
+		ExportRetention ExportRetention  `yaml:"export_retention"`
+		FitMode         string           `yaml:"fit_mode"`
+		FillColor       string           `yaml:"fill_color"`
+		ResampleFilter  string           `yaml:"resample_filter"`
+		PNGCompression  string           `yaml:"png_compression"`
+		JPEGQuality     int              `yaml:"jpeg_quality"`
+		Dither          string           `yaml:"dither"`
+		ColorSpace      string           `yaml:"color_space"`
 	} `yaml:"output"`
+	Watermark WatermarkConfig `yaml:"watermark"`
+	Composite CompositeConfig `yaml:"composite"`
+	Pipeline  []PipelineStep  `yaml:"pipeline"`
+}
+
+type EnhancementConfig struct {
+	Temperature       float64                        `yaml:"temperature"`
+	Tint              float64                        `yaml:"tint"`
+	TemperatureKelvin float64                        `yaml:"temperature_kelvin"`
+	AutoWhiteBalance  string                         `yaml:"auto_white_balance"`
+	Contrast          float64                        `yaml:"contrast"`
+	Saturation        float64                        `yaml:"saturation"`
+	Brightness        float64                        `yaml:"brightness"`
+	Hue               float64                        `yaml:"hue"`
+	Gamma             float64                        `yaml:"gamma"`
+	BlackPoint        float64                        `yaml:"black_point"`
+	WhitePoint        float64                        `yaml:"white_point"`
+	ShadowStrength    float64                        `yaml:"shadow_strength"`
+	CLAHEClipLimit    float64                        `yaml:"clahe_clip_limit"`
+	CLAHETiles        int                            `yaml:"clahe_tiles"`
+	AutoLevels        bool                           `yaml:"auto_levels"`
+	AutoLevelsClip    float64                        `yaml:"auto_levels_clip"`
+	LUT               string                         `yaml:"lut"`
+	LUTStrength       *float64                       `yaml:"lut_strength"`
+	ToneCurve         adjustment.ToneCurve           `yaml:"tone_curve"`
+	HSL               map[string]adjustment.HSLShift `yaml:"hsl"`
 }
 
 func NewWallpaperManager(configPath string) (*WallpaperManager, error) {
@@ -114,6 +167,56 @@ func (wm *WallpaperManager) LoadConfig(path string) error {
 		return err
 	}
 
+	if err := wm.prepareOutput(); err != nil {
+		logger.WithError(err).Error("Invalid output configuration")
+		return err
+	}
+
+	if err := wm.validateBlend(); err != nil {
+		logger.WithError(err).Error("Invalid blend configuration")
+		return err
+	}
+
+	if err := wm.validateTransition(); err != nil {
+		logger.WithError(err).Error("Invalid transition configuration")
+		return err
+	}
+
+	if err := wm.validateWatermark(); err != nil {
+		logger.WithError(err).Error("Invalid watermark configuration")
+		return err
+	}
+
+	if err := wm.validateSources(); err != nil {
+		logger.WithError(err).Error("Invalid input configuration")
+		return err
+	}
+
+	if err := wm.validateStaleDetection(); err != nil {
+		logger.WithError(err).Error("Invalid stale detection configuration")
+		return err
+	}
+
+	if err := wm.validateComposite(); err != nil {
+		logger.WithError(err).Error("Invalid composite configuration")
+		return err
+	}
+
+	if err := wm.validateEnhancements(); err != nil {
+		logger.WithError(err).Error("Invalid image processing configuration")
+		return err
+	}
+
+	if err := wm.validateLUTs(); err != nil {
+		logger.WithError(err).Error("Invalid LUT configuration")
+		return err
+	}
+
+	if err := wm.validatePipeline(); err != nil {
+		logger.WithError(err).Error("Invalid processing pipeline")
+		return err
+	}
+
 	logger.WithField("path", path).Debug("Configuration loaded successfully")
 	return nil
 }
@@ -123,24 +226,73 @@ func (wm *WallpaperManager) setWallpaper(filepath string) error {
 	return SetWallpaper(filepath)
 }
 
-func (wm *WallpaperManager) enhanceImage(img image.Image) image.Image {
+// enhancementConfigs returns the profile enhancement settings and those of all composite tiles
+func (wm *WallpaperManager) enhancementConfigs() []EnhancementConfig {
+	configs := []EnhancementConfig{wm.WallpaperManagerConfig.ImageProcessing.EnhancementConfig}
+	for _, tile := range wm.WallpaperManagerConfig.Composite.Tiles {
+		if tile.ImageProcessing != nil {
+			configs = append(configs, *tile.ImageProcessing)
+		}
+	}
+	return configs
+}
+
+func (wm *WallpaperManager) validateEnhancements() error {
+	for _, config := range wm.enhancementConfigs() {
+		if err := adjustment.ValidateWhiteBalance(config.AutoWhiteBalance); err != nil {
+			return err
+		}
+		if config.Temperature < -1 || config.Temperature > 1 || config.Tint < -1 || config.Tint > 1 {
+			return fmt.Errorf("temperature and tint must be between -1 and 1")
+		}
+		if config.TemperatureKelvin != 0 && (config.TemperatureKelvin < 1000 || config.TemperatureKelvin > 40000) {
+			return fmt.Errorf("temperature_kelvin must be between 1000 and 40000: %v", config.TemperatureKelvin)
+		}
+		if err := config.ToneCurve.Validate(); err != nil {
+			return fmt.Errorf("invalid tone curve: %w", err)
+		}
+		if err := adjustment.ValidateHSL(config.HSL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (wm *WallpaperManager) enhanceImage(img image.Image, config EnhancementConfig) image.Image {
 	processor := adjustment.NewImageEnhancer()
-	processor.Contrast = wm.WallpaperManagerConfig.ImageProcessing.Contrast
-	processor.Saturation = wm.WallpaperManagerConfig.ImageProcessing.Saturation
-	processor.Brightness = wm.WallpaperManagerConfig.ImageProcessing.Brightness
-	processor.Hue = wm.WallpaperManagerConfig.ImageProcessing.Hue
-	processor.Gamma = wm.WallpaperManagerConfig.ImageProcessing.Gamma
-	processor.BlackPoint = wm.WallpaperManagerConfig.ImageProcessing.BlackPoint
-	processor.WhitePoint = wm.WallpaperManagerConfig.ImageProcessing.WhitePoint
-	processor.ShadowStrength = wm.WallpaperManagerConfig.ImageProcessing.ShadowStrength
-
-	enhancedImg := processor.ApplyEnhancements(img)
-	sharpenedImage := imaging.Sharpen(enhancedImg, wm.WallpaperManagerConfig.ImageProcessing.SharpenStrength)
-
-	srcWidth := sharpenedImage.Bounds().Dx()
-	srcHeight := sharpenedImage.Bounds().Dy()
-	targetWidth := wm.WallpaperConfig.TargetDimensions.Width
-	targetHeight := wm.WallpaperConfig.TargetDimensions.Height
+	processor.Temperature = config.Temperature
+	processor.Tint = config.Tint
+	processor.TemperatureKelvin = config.TemperatureKelvin
+	processor.AutoWhiteBalance = config.AutoWhiteBalance
+	processor.Contrast = config.Contrast
+	processor.Saturation = config.Saturation
+	processor.Brightness = config.Brightness
+	processor.Hue = config.Hue
+	processor.Gamma = config.Gamma
+	processor.BlackPoint = config.BlackPoint
+	processor.WhitePoint = config.WhitePoint
+	processor.ShadowStrength = config.ShadowStrength
+	processor.CLAHEClipLimit = config.CLAHEClipLimit
+	processor.CLAHETiles = config.CLAHETiles
+	processor.AutoLevels = config.AutoLevels
+	processor.AutoLevelsClip = config.AutoLevelsClip
+	processor.ToneCurve = config.ToneCurve
+	processor.HSLAdjustments = config.HSL
+	processor.LinearLight = wm.linearLight()
+
+	if wm.highPrecision() {
+		return wm.applyLUT(processor.ApplyEnhancements64(img), config)
+	}
+	return wm.applyLUT(processor.ApplyEnhancements(img), config)
+}
+
+func (wm *WallpaperManager) sharpenImage(img image.Image, strength float64) image.Image {
+	return imaging.Sharpen(img, strength)
+}
+
+func (wm *WallpaperManager) resizeImage(img image.Image, targetWidth, targetHeight int, cropMode string, anchor *postprocess.Anchor) image.Image {
+	srcWidth := img.Bounds().Dx()
+	srcHeight := img.Bounds().Dy()
 
 	widthRatio := float64(targetWidth) / float64(srcWidth)
 	heightRatio := float64(targetHeight) / float64(srcHeight)
@@ -150,7 +302,11 @@ func (wm *WallpaperManager) enhanceImage(img image.Image) image.Image {
 	newWidth := int(float64(srcWidth) * scale)
 	newHeight := int(float64(srcHeight) * scale)
 
-	resized := imaging.Resize(sharpenedImage, newWidth, newHeight, imaging.Lanczos)
+	resized := wm.resample(img, newWidth, newHeight)
+
+	if cropMode == postprocess.CropModeSmart {
+		return wm.crop(resized, postprocess.SmartCrop(resized, targetWidth, targetHeight, anchor))
+	}
 
 	cropX := (newWidth - targetWidth) / 2
 	cropY := (newHeight - targetHeight) / 2
@@ -159,11 +315,17 @@ func (wm *WallpaperManager) enhanceImage(img image.Image) image.Image {
 		Max: image.Point{cropX + targetWidth, cropY + targetHeight},
 	}
 
-	return imaging.Crop(resized, cropRect)
+	return wm.crop(resized, cropRect)
 }
 
-func (wm *WallpaperManager) applyBlur(img image.Image) image.Image {
-	return imaging.Blur(img, wm.WallpaperManagerConfig.ImageProcessing.BlurStrength)
+func (wm *WallpaperManager) applyBlur(img image.Image, strength float64) image.Image {
+	if wm.linearLight() {
+		return postprocess.FromLinear(postprocess.Blur64(postprocess.ToLinear(img), strength))
+	}
+	if wm.highPrecision() {
+		return postprocess.Blur64(img, strength)
+	}
+	return imaging.Blur(img, strength)
 }
 
 func (wm *WallpaperManager) applyNoise(img image.Image, maxOpacity float64, scale int) image.Image {
@@ -195,55 +357,48 @@ func (wm *WallpaperManager) applyNoise(img image.Image, maxOpacity float64, scal
 
 		blurredNoiseImg := imaging.Blur(resizedNoiseImg, 1.0)
 
-		return imaging.Overlay(img, blurredNoiseImg, image.Pt(0, 0), scaledOpacity)
+		return wm.overlay(img, blurredNoiseImg, image.Pt(0, 0), scaledOpacity)
 	}
 
 	logger.Debug("Image is not bright enough, returning original image")
 	return img
 }
 
-func (wm *WallpaperManager) cropImage(img image.Image, factor, offsetX, offsetY float64) image.Image {
+func (wm *WallpaperManager) cropImage(img image.Image, factor, offsetX, offsetY float64, cropMode string, anchor *postprocess.Anchor) image.Image {
+	if factor <= 0 {
+		factor = 1
+	}
+
 	width := img.Bounds().Dx()
 	height := img.Bounds().Dy()
 	cropWidth := int(float64(width) / factor)
 	cropHeight := int(float64(height) / factor)
+
+	if cropMode == postprocess.CropModeSmart {
+		return wm.crop(img, postprocess.SmartCrop(img, cropWidth, cropHeight, anchor))
+	}
+
 	cropRect := image.Rect(
 		(width-cropWidth)/2+int(offsetX*float64(width)),
 		(height-cropHeight)/2+int(offsetY*float64(height)),
 		(width+cropWidth)/2+int(offsetX*float64(width)),
 		(height+cropHeight)/2+int(offsetY*float64(height)),
 	)
-	return imaging.Crop(img, cropRect)
+	return wm.crop(img, cropRect)
 }
 
-func (wm *WallpaperManager) processImage(tempPath, finalImagePath string) (image.Image, error) {
+func (wm *WallpaperManager) processImage(tempPath, finalImagePath string, source InputSource) (image.Image, error) {
 	logger.WithField("tempPath", tempPath).WithField("finalImagePath", finalImagePath).Debug("Processing image")
-	img, err := imaging.Open(tempPath)
+	img, err := wm.openFrame(tempPath)
 	if err != nil {
 		logger.WithError(err).Error("Failed to open image")
 		return nil, err
 	}
 
-	finalImage := wm.cropImage(img, wm.WallpaperManagerConfig.Input.CropFactor, wm.WallpaperManagerConfig.Input.OffsetX, wm.WallpaperManagerConfig.Input.OffsetY)
-	finalImage = wm.enhanceImage(finalImage)
-	finalImage = wm.applyBlur(finalImage)
-	finalImage = wm.applyNoise(finalImage, wm.WallpaperManagerConfig.ImageProcessing.MaxNoiseOpacity, wm.WallpaperManagerConfig.ImageProcessing.NoiseScale)
-
-	watermarkPath := filepath.Join(filepath.Dir(wm.configPath), "watermark.png")
-	watermark, err := imaging.Open(watermarkPath)
-	if err == nil {
-		if watermark.Bounds().Dy() > MaxWatermarkHeight {
-			ratio := float64(MaxWatermarkHeight) / float64(watermark.Bounds().Dy())
-			newWidth := int(float64(watermark.Bounds().Dx()) * ratio)
-			watermark = imaging.Resize(watermark, newWidth, MaxWatermarkHeight, imaging.Lanczos)
-		}
-
-		offset := image.Pt(finalImage.Bounds().Dx()-watermark.Bounds().Dx()-20, finalImage.Bounds().Dy()-watermark.Bounds().Dy()-20)
-
-		avgBrightness := postprocess.CalculateAverageBrightness(img)
-		scaledOpacity := postprocess.CalculateScaledOpacity(avgBrightness, 0.0, 0.4, 0.2, 0.8)
-
-		finalImage = imaging.Overlay(finalImage, watermark, offset, scaledOpacity)
+	finalImage, err := wm.runPipeline(&pipelineContext{original: img, input: source})
+	if err != nil {
+		logger.WithError(err).Error("Failed to run processing pipeline")
+		return nil, err
 	}
 
 	return finalImage, nil
@@ -288,57 +443,88 @@ func (wm *WallpaperManager) fetchAndProcessImage(tempImageFilePath, previousProc
 	var finalImage image.Image
 	var err error
 
-	logger.WithField("tempImageFilePath", tempImageFilePath).WithField("imageFilePath", imageFilePath).Debug("Fetching new image from source")
-	if err := util.DownloadImage(wm.WallpaperManagerConfig.Input.URL, tempImageFilePath, false); err != nil {
-		logger.WithError(err).Warn("Failed to download image")
-		return nil, err
-	}
+	logger.WithField("tempImageFilePath", tempImageFilePath).WithField("imageFilePath", imageFilePath).Debug("Fetching new image")
+	if wm.compositeEnabled() {
+		var tiles []image.Image
+		tiles, err = wm.fetchTiles(filepath.Dir(tempImageFilePath))
+		if err != nil {
+			logger.WithError(err).Warn("Failed to fetch composite tiles")
+			return nil, err
+		}
 
-	logger.Debug("Sanitizing downloaded image")
-	if err := sanitizer.SanitizeImage(tempImageFilePath); err != nil {
-		logger.WithError(err).Fatal("Failed to sanitize image")
+		logger.Debug("Processing new composite")
+		finalImage, err = wm.processComposite(tiles)
+	} else {
+		var source InputSource
+		source, err = wm.fetchSource(tempImageFilePath)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to fetch image from any source")
+			return nil, err
+		}
+
+		if wm.staleDetectionEnabled() {
+			reuse, err := wm.reusePreviousFrame(source, tempImageFilePath, previousProcImageFilePath)
+			if err != nil {
+				return nil, err
+			}
+			if reuse != nil {
+				return reuse, nil
+			}
+		}
+
+		logger.Debug("Processing new image")
+		finalImage, err = wm.processImage(tempImageFilePath, imageFilePath, source)
+	}
+	if err != nil {
+		logger.WithError(err).Error("Failed to process image")
 		return nil, err
 	}
 
-	logger.Debug("Processing new image")
-	finalImage, err = wm.processImage(tempImageFilePath, imageFilePath)
+	return wm.applyBlend(finalImage, previousProcImageFilePath), nil
+}
+
+// reusePreviousFrame returns previous processed frame when downloaded frame is
+// unchanged, so identical input does not go through the pipeline again
+func (wm *WallpaperManager) reusePreviousFrame(source InputSource, tempImageFilePath, previousProcImageFilePath string) (image.Image, error) {
+	img, err := imaging.Open(tempImageFilePath)
 	if err != nil {
-		logger.WithError(err).Fatal("Failed to process image")
+		logger.WithError(err).Warn("Failed to open image for stale detection")
+		return nil, nil
+	}
+
+	reuse, err := wm.checkStale(source, img, previousProcImageFilePath)
+	if err != nil || !reuse {
 		return nil, err
 	}
 
-	if wm.WallpaperManagerConfig.Output.Blend && util.FileExists(previousProcImageFilePath) {
-		previousImage, err := util.LoadImageFile(previousProcImageFilePath)
-		if err != nil {
-			logger.WithError(err).Warn("Failed to load previous processed image")
-		} else {
-			finalImage = imaging.Overlay(finalImage, previousImage, image.Pt(0, 0), 0.5)
-		}
+	previousImage, err := wm.currentFrame(previousProcImageFilePath)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to load previous processed image")
+		return nil, nil
 	}
 
-	return finalImage, nil
+	logger.Debug("Frame unchanged, reusing previous processed image")
+	return previousImage, nil
 }
 
-func (wm *WallpaperManager) saveFinalImage(finalImage image.Image, imageFilePath, previousProcImageFilePath string, pngCompressionLevel imaging.EncodeOption) error {
-	if err := imaging.Save(finalImage, previousProcImageFilePath, pngCompressionLevel); err != nil {
-		logger.WithError(err).Fatal("Failed to save processed image")
-		return err
-	}
-
+// saveFinalImage renders the current frame and saves it as the wallpaper
+func (wm *WallpaperManager) saveFinalImage(imageFilePath string, pngCompressionLevel imaging.EncodeOption, fetchSource bool) error {
 	if err := os.MkdirAll(filepath.Dir(imageFilePath), 0750); err != nil {
 		logger.WithError(err).Fatal("Failed to create savePathFilePath directory")
 		return err
 	}
 
-	if !wm.WallpaperConfig.DisableClock {
-		finalImage = render.DrawTimeOnImage(finalImage, wm.WallpaperConfig.FontConfigClock)
-	}
+	finalImage := wm.renderFrame()
 
-	if err := imaging.Save(finalImage, imageFilePath, pngCompressionLevel); err != nil {
+	if err := wm.saveTaggedImage(finalImage, imageFilePath, pngCompressionLevel); err != nil {
 		logger.WithError(err).Fatal("Failed to save final image")
 		return err
 	}
 
+	if err := wm.saveExport(finalImage, fetchSource); err != nil {
+		logger.WithError(err).Warn("Failed to save export image")
+	}
+
 	return nil
 }
 
@@ -361,15 +547,7 @@ func (wm *WallpaperManager) convertAndArchiveJPEG(imageFilePath, latestFilePath
 		jpgFilePath = latestFilePath[:len(latestFilePath)-len(filepath.Ext(latestFilePath))] + ".jpg"
 	}
 
-	jpgFile, err := os.Create(filepath.Clean(jpgFilePath))
-	if err != nil {
-		logger.WithError(err).Warning("Failed to create JPEG file")
-		return "", err
-	}
-	defer jpgFile.Close()
-
-	opts := &jpeg.Options{Quality: 100}
-	if err := jpeg.Encode(jpgFile, img, opts); err != nil {
+	if err := wm.saveTaggedImage(img, jpgFilePath, imaging.JPEGQuality(100)); err != nil {
 		logger.WithError(err).Warning("Failed to encode image as JPEG")
 		return "", err
 	}
@@ -392,9 +570,29 @@ func (wm *WallpaperManager) convertAndArchiveJPEG(imageFilePath, latestFilePath
 		return "", err
 	}
 
+	wm.applyArchiveRetention(archiveFolderPath)
+
 	return archiveFilePath, nil
 }
 
+// applyArchiveRetention prunes archived JPEGs according to configured retention policy
+func (wm *WallpaperManager) applyArchiveRetention(archiveFolderPath string) {
+	policy := wm.WallpaperConfig.ArchiveRetention
+	if !policy.Enabled() {
+		return
+	}
+
+	janitor := repository.NewJanitor(".jpg")
+	removed, err := janitor.ApplyRetention(archiveFolderPath, policy, time.Now())
+	if err != nil {
+		logger.WithError(err).Warning("Failed to apply archive retention")
+		return
+	}
+	if removed > 0 {
+		logger.WithField("removed", removed).Debug("Pruned archived wallpapers")
+	}
+}
+
 func (wm *WallpaperManager) applyWallpaper(imageFilePath, latestFilePath string) error {
 	// Set wallpaper if update is not disabled
 	if !wm.WallpaperConfig.DisableOSWallpaperUpdate {
@@ -423,7 +621,23 @@ func (wm *WallpaperManager) applyWallpaper(imageFilePath, latestFilePath string)
 	return nil
 }
 
+// Shutdown waits for an in-flight update to finish and rejects further updates
+func (wm *WallpaperManager) Shutdown() {
+	wm.updateLock.Lock()
+	defer wm.updateLock.Unlock()
+
+	wm.shutdown = true
+}
+
 func (wm *WallpaperManager) UpdateWallpaper(fetchSource, deepClean bool) {
+	wm.updateLock.Lock()
+	defer wm.updateLock.Unlock()
+
+	if wm.shutdown {
+		logger.Debug("Shutdown in progress, skipping wallpaper update")
+		return
+	}
+
 	logger.WithField("fetchSource", fetchSource).WithField("deepClean", deepClean).Debug("Updating wallpaper")
 
 	if !fetchSource && deepClean {
@@ -442,14 +656,15 @@ func (wm *WallpaperManager) UpdateWallpaper(fetchSource, deepClean bool) {
 	}
 
 	wallpaperDirName := "files"
-	hash := util.GenerateShortHash(wm.WallpaperManagerConfig.Input.URL, timestampStr)
-	urlHash := util.GenerateShortHash(wm.WallpaperManagerConfig.Input.URL, "")
+	hash := util.GenerateShortHash(wm.primaryURL(), timestampStr)
+	urlHash := util.GenerateShortHash(wm.primaryURL(), "")
 
 	wallpaperPath := filepath.Join(appDirPath, wallpaperDirName, urlHash)
 	tempImagePath := filepath.Join(wallpaperPath, ".tmp")
 	tempImageFilePath := filepath.Join(tempImagePath, "image")
 	previousProcImageFilePath := filepath.Join(tempImagePath, "cache"+FileType)
 	imagePath := filepath.Join(wallpaperPath, "proc")
+	stackPath := filepath.Join(wallpaperPath, stackDirName)
 	imageFilePath := filepath.Join(imagePath, hash+FileType)
 	latestFilePath := filepath.Join(appDirPath, "latest"+FileType)
 
@@ -458,40 +673,76 @@ func (wm *WallpaperManager) UpdateWallpaper(fetchSource, deepClean bool) {
 		logger.WithError(err).WithField("deepClean", deepClean).WithField("tempImagePath", tempImagePath).Fatal("Failed to clean up old files")
 	}
 	if deepClean {
+		wm.resetFrame()
 		err = wm.cleanUpOldFiles(janitor, tempImagePath, true)
 
 		if err != nil {
 			logger.WithError(err).WithField("deepClean", deepClean).WithField("tempImagePath", tempImagePath).Fatal("Failed to clean up old files")
 		}
+
+		err = wm.cleanUpOldFiles(janitor, stackPath, true)
+		if err != nil {
+			logger.WithError(err).WithField("deepClean", deepClean).WithField("stackPath", stackPath).Fatal("Failed to clean up old files")
+		}
 	}
 
 	if err := wm.prepareDirectories(tempImageFilePath, imageFilePath); err != nil {
 		logger.WithError(err).Fatal("Failed to prepare directories")
 	}
 
+	var transitionBase image.Image
+	if fetchSource {
+		transitionBase = wm.loadTransitionBase(previousProcImageFilePath)
+	}
+
+	pngCompressionLevel := imaging.PNGCompressionLevel(png.NoCompression)
+
 	var finalImage image.Image
 	if fetchSource {
 		finalImage, err = wm.fetchAndProcessImage(tempImageFilePath, previousProcImageFilePath, imageFilePath)
+		if errors.Is(err, errStaleSource) {
+			logger.Info("Skipping update for stale source")
+			return
+		}
 		if err != nil {
 			logger.WithError(err).Error("Failed to fetch and process image")
 			return
 		}
-	} else if util.FileExists(previousProcImageFilePath) {
-		finalImage, err = util.LoadImageFile(previousProcImageFilePath)
+
+		if err := saveImage(finalImage, previousProcImageFilePath, pngCompressionLevel); err != nil {
+			logger.WithError(err).Fatal("Failed to save processed image")
+			return
+		}
+		wm.setFrame(previousProcImageFilePath, finalImage)
+	} else if wm.frameState.path == previousProcImageFilePath || util.FileExists(previousProcImageFilePath) {
+		finalImage, err = wm.currentFrame(previousProcImageFilePath)
 		if err != nil {
 			logger.WithError(err).Warn("Failed to load previous processed image")
 			return
 		}
+	} else {
+		logger.Debug("No processed image yet, skipping update")
+		return
 	}
 
-	pngCompressionLevel := imaging.PNGCompressionLevel(png.NoCompression)
-	if err := wm.saveFinalImage(finalImage, imageFilePath, previousProcImageFilePath, pngCompressionLevel); err != nil {
+	if err := wm.saveFinalImage(imageFilePath, pngCompressionLevel, fetchSource); err != nil {
 		return
 	}
 
+	framePaths := wm.playTransition(transitionBase, finalImage, tempImagePath)
+
 	if err := wm.applyWallpaper(imageFilePath, latestFilePath); err != nil {
+		// The desktop may still show the last transition frame
+		if len(framePaths) > 0 {
+			removeTransitionFrames(framePaths[:len(framePaths)-1])
+		}
 		return
 	}
+	removeTransitionFrames(framePaths)
+
+	if fetchSource {
+		wm.collectGarbage(filepath.Join(appDirPath, wallpaperDirName))
+	}
 
 	wm.updateCount++
 	timeSpend := time.Duration(time.Now().UTC().UnixNano() - startTime)
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/disintegration/imaging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newImageServer serves a small PNG at every path
+func newImageServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_ = png.Encode(w, imaging.New(32, 32, color.NRGBA{90, 120, 150, 255}))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchAndProcessImageProcessingError(t *testing.T) {
+	server := newImageServer(t)
+
+	failingPipeline := []PipelineStep{{Step: "unknown"}}
+	cases := map[string]func(wm *WallpaperManager){
+		"single source": func(wm *WallpaperManager) {
+			wm.WallpaperManagerConfig.Input.URL = server.URL + "/frame.png"
+		},
+		"composite": func(wm *WallpaperManager) {
+			wm.WallpaperManagerConfig.Composite.Tiles = []CompositeTile{{InputSource: InputSource{URL: server.URL + "/tile.png"}}}
+		},
+	}
+
+	for name, configure := range cases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			wm := &WallpaperManager{}
+			wm.WallpaperManagerConfig.Pipeline = failingPipeline
+			configure(wm)
+
+			img, err := wm.fetchAndProcessImage(filepath.Join(dir, "image"), filepath.Join(dir, "cache.png"), filepath.Join(dir, "proc.png"))
+			require.Error(t, err, "Failing pipeline should be reported")
+			assert.Contains(t, err.Error(), "unknown", "Error should name the failing step")
+			assert.Nil(t, img, "No image should be returned for a failed run")
+		})
+	}
+}
+
+func TestFetchAndProcessImage(t *testing.T) {
+	server := newImageServer(t)
+	dir := t.TempDir()
+
+	wm := &WallpaperManager{}
+	wm.WallpaperManagerConfig.Input.URL = server.URL + "/frame.png"
+	wm.WallpaperManagerConfig.Pipeline = []PipelineStep{{Step: StepEnhance}}
+
+	img, err := wm.fetchAndProcessImage(filepath.Join(dir, "image"), filepath.Join(dir, "cache.png"), filepath.Join(dir, "proc.png"))
+	require.NoError(t, err, "Valid pipeline should succeed")
+	assert.Equal(t, image.Rect(0, 0, 32, 32), img.Bounds(), "Processed frame should keep source size")
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+
+	"github.com/TilmanGriesel/AlpineZen/pkg/postprocess"
+	"github.com/TilmanGriesel/AlpineZen/pkg/util"
+	"github.com/disintegration/imaging"
+)
+
+const (
+	WatermarkTopLeft     = "top_left"
+	WatermarkTopRight    = "top_right"
+	WatermarkBottomLeft  = "bottom_left"
+	WatermarkBottomRight = "bottom_right"
+
+	DefaultWatermarkFile       = "watermark.png"
+	DefaultWatermarkMargin     = 20
+	DefaultWatermarkMinOpacity = 0.2
+	DefaultWatermarkMaxOpacity = 0.8
+)
+
+// WatermarkConfig places the profile watermark. Path is relative to the
+// profile directory, MaxHeight is a fraction of output height.
+type WatermarkConfig struct {
+	Disable    bool     `yaml:"disable"`
+	Path       string   `yaml:"path"`
+	Anchor     string   `yaml:"anchor"`
+	MarginX    *int     `yaml:"margin_x"`
+	MarginY    *int     `yaml:"margin_y"`
+	MaxHeight  float64  `yaml:"max_height"`
+	MinOpacity *float64 `yaml:"min_opacity"`
+	MaxOpacity *float64 `yaml:"max_opacity"`
+}
+
+func (wm *WallpaperManager) validateWatermark() error {
+	config := wm.WallpaperManagerConfig.Watermark
+	if config.Path != "" && !filepath.IsLocal(config.Path) {
+		return fmt.Errorf("watermark must be located in the profile directory: %q", config.Path)
+	}
+
+	switch config.Anchor {
+	case "", WatermarkTopLeft, WatermarkTopRight, WatermarkBottomLeft, WatermarkBottomRight:
+	default:
+		return fmt.Errorf("unknown watermark anchor: %q", config.Anchor)
+	}
+
+	if config.MaxHeight < 0 || config.MaxHeight > 1 {
+		return fmt.Errorf("watermark max_height must be a fraction between 0 and 1: %v", config.MaxHeight)
+	}
+
+	minOpacity := floatOrDefault(config.MinOpacity, DefaultWatermarkMinOpacity)
+	maxOpacity := floatOrDefault(config.MaxOpacity, DefaultWatermarkMaxOpacity)
+	if minOpacity < 0 || maxOpacity > 1 || minOpacity > maxOpacity {
+		return fmt.Errorf("invalid watermark opacity range: %v to %v", minOpacity, maxOpacity)
+	}
+
+	return nil
+}
+
+// watermarkPath returns the watermark file in the profile directory, or an
+// empty string when the configured path leaves it
+func (wm *WallpaperManager) watermarkPath() string {
+	path := wm.WallpaperManagerConfig.Watermark.Path
+	if path == "" {
+		path = DefaultWatermarkFile
+	}
+	if !filepath.IsLocal(path) {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(wm.configPath), path)
+}
+
+// loadWatermark loads the watermark once through a sanitized copy. The
+// sanitizer rewrites files in place, so the profile's file is left untouched.
+func (wm *WallpaperManager) loadWatermark() image.Image {
+	if wm.watermarkLoaded {
+		return wm.watermark
+	}
+	wm.watermarkLoaded = true
+
+	path := wm.watermarkPath()
+	if path == "" || !util.FileExists(path) {
+		return nil
+	}
+
+	watermark, err := wm.loadSanitizedImage(path)
+	if err != nil {
+		logger.WithError(err).WithField("path", path).Warn("Failed to load watermark")
+		return nil
+	}
+
+	wm.watermark = watermark
+	return wm.watermark
+}
+
+func (wm *WallpaperManager) loadSanitizedImage(path string) (image.Image, error) {
+	tempFile, err := os.CreateTemp("", "alpinezen_watermark_*")
+	if err != nil {
+		return nil, err
+	}
+	tempPath := tempFile.Name()
+	tempFile.Close()
+	defer os.Remove(tempPath)
+
+	if err := util.CopyFile(path, tempPath); err != nil {
+		return nil, err
+	}
+	if err := wm.sanitizeFrame(tempPath); err != nil {
+		return nil, fmt.Errorf("failed to sanitize image: %w", err)
+	}
+	return wm.openFrame(tempPath)
+}
+
+// applyWatermark draws the watermark with opacity scaled by brightness of the source frame
+func (wm *WallpaperManager) applyWatermark(img image.Image, sourceStats *postprocess.ImageStats) image.Image {
+	config := wm.WallpaperManagerConfig.Watermark
+	if config.Disable {
+		return img
+	}
+
+	watermark := wm.loadWatermark()
+	if watermark == nil {
+		return img
+	}
+
+	maxHeight := MaxWatermarkHeight
+	if config.MaxHeight > 0 {
+		maxHeight = max(1, int(config.MaxHeight*float64(img.Bounds().Dy())))
+	}
+	if watermark.Bounds().Dy() > maxHeight {
+		watermark = imaging.Resize(watermark, 0, maxHeight, wm.resampleFilter)
+	}
+
+	marginX := intOrDefault(config.MarginX, DefaultWatermarkMargin)
+	marginY := intOrDefault(config.MarginY, DefaultWatermarkMargin)
+	left := marginX
+	right := img.Bounds().Dx() - watermark.Bounds().Dx() - marginX
+	top := marginY
+	bottom := img.Bounds().Dy() - watermark.Bounds().Dy() - marginY
+
+	var offset image.Point
+	switch config.Anchor {
+	case WatermarkTopLeft:
+		offset = image.Pt(left, top)
+	case WatermarkTopRight:
+		offset = image.Pt(right, top)
+	case WatermarkBottomLeft:
+		offset = image.Pt(left, bottom)
+	default:
+		offset = image.Pt(right, bottom)
+	}
+
+	minOpacity := floatOrDefault(config.MinOpacity, DefaultWatermarkMinOpacity)
+	maxOpacity := floatOrDefault(config.MaxOpacity, DefaultWatermarkMaxOpacity)
+	scaledOpacity := postprocess.CalculateScaledOpacity(sourceStats.Mean, 0.0, 0.4, minOpacity, maxOpacity)
+
+	return wm.overlay(img, watermark, offset, scaledOpacity)
+}
@@ -0,0 +1,40 @@
+// SPDX-FileCopyrightText: 2025 Tilman Griesel
+//
+// SPDX-License-Identifier: GPL-3.0-or-later AND LicenseRef-AlpineZen-Trademark
+
+package wallpaper
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatermarkPathStaysInProfile(t *testing.T) {
+	profileDir := filepath.Join("profiles", "alps")
+	tests := []struct {
+		path     string
+		expected string
+		valid    bool
+	}{
+		{"", filepath.Join(profileDir, DefaultWatermarkFile), true},
+		{"logo.png", filepath.Join(profileDir, "logo.png"), true},
+		{"assets/logo.png", filepath.Join(profileDir, "assets", "logo.png"), true},
+		{"/etc/passwd", "", false},
+		{"../other/watermark.png", "", false},
+		{"assets/../../watermark.png", "", false},
+	}
+
+	for _, tt := range tests {
+		wm := &WallpaperManager{configPath: filepath.Join(profileDir, "config.yaml")}
+		wm.WallpaperManagerConfig.Watermark.Path = tt.path
+
+		assert.Equal(t, tt.expected, wm.watermarkPath(), "Watermark path %q should resolve inside the profile only", tt.path)
+		if tt.valid {
+			assert.NoError(t, wm.validateWatermark(), "Watermark path %q should be accepted", tt.path)
+		} else {
+			assert.Error(t, wm.validateWatermark(), "Watermark path %q should be rejected", tt.path)
+		}
+	}
+}